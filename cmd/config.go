@@ -0,0 +1,192 @@
+/*
+Copyright © 2023 Matt Simons
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/testernetes/bdk/model"
+)
+
+const configFileName = "bdk.yaml"
+
+var configFile string
+var profile string
+
+// pathFlags are flags whose relative paths in the config file are relative to the
+// directory of the config file
+var pathFlags = map[string]bool{
+	"artifacts-dir":                true,
+	"fixtures-dir":                 true,
+	"format-junit-attachments-dir": true,
+	"kubeconfig":                   true,
+	"plugins":                      true,
+	"schemas":                      true,
+}
+
+// findConfig returns the first bdk.yaml found in the directory of each path or in
+// any of its parents
+func findConfig(paths []string) (string, error) {
+	for _, p := range paths {
+		dir, err := filepath.Abs(p)
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		for {
+			candidate := filepath.Join(dir, configFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return "", nil
+}
+
+// loadConfig reads the config file and selected profile and then sets every flag which
+// was not given on the command line from a BDK_* environment variable or the config.
+// The tag-settings of the config are returned.
+//
+//	format: junit
+//	tags: "@smoke"
+//	parallel: 4
+//	var:
+//	  registry: ghcr.io/testernetes
+//	tag-settings:
+//	- tags: "@slow"
+//	  timeout: 10m
+//	profiles:
+//	  ci:
+//	    fast-fail: true
+func loadConfig(cmd *cobra.Command, args []string) ([]model.TagSettings, error) {
+	viper.SetEnvPrefix("BDK")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	path := configFile
+	if path == "" {
+		var err error
+		path, err = findConfig(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path != "" {
+		viper.SetConfigFile(path)
+		err := viper.ReadInConfig()
+		if err != nil {
+			return nil, fmt.Errorf("could not read config %s: %w", path, err)
+		}
+	}
+
+	if profile == "" {
+		profile = viper.GetString("profile")
+	}
+	if profile != "" {
+		key := "profiles." + profile
+		if !viper.IsSet(key) {
+			return nil, fmt.Errorf("profile %s is not defined in %s", profile, path)
+		}
+		err := viper.MergeConfigMap(viper.GetStringMap(key))
+		if err != nil {
+			return nil, fmt.Errorf("could not use profile %s: %w", profile, err)
+		}
+	}
+
+	var errs error
+	for _, fs := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if f.Changed || !viper.IsSet(f.Name) {
+				return
+			}
+			value := viper.Get(f.Name)
+			if pathFlags[f.Name] && path != "" && !fromEnv(f.Name) {
+				value = relativeTo(filepath.Dir(path), value)
+			}
+			err := setFlag(fs, f, value)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("invalid %s in config: %w", f.Name, err))
+			}
+		})
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	var tagSettings []model.TagSettings
+	err := viper.UnmarshalKey("tag-settings", &tagSettings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag-settings in config: %w", err)
+	}
+	return tagSettings, nil
+}
+
+// fromEnv is true if the flag is set by a BDK_* environment variable
+func fromEnv(name string) bool {
+	_, ok := os.LookupEnv("BDK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+	return ok
+}
+
+// relativeTo joins dir with each relative path of a config value
+func relativeTo(dir string, value any) any {
+	switch v := value.(type) {
+	case string:
+		if v == "" || filepath.IsAbs(v) {
+			return v
+		}
+		return filepath.Join(dir, v)
+	case []any:
+		paths := make([]any, len(v))
+		for i, e := range v {
+			paths[i] = relativeTo(dir, fmt.Sprint(e))
+		}
+		return paths
+	}
+	return value
+}
+
+func setFlag(fs *pflag.FlagSet, f *pflag.Flag, value any) error {
+	switch v := value.(type) {
+	case []any:
+		var s []string
+		for _, e := range v {
+			s = append(s, fmt.Sprint(e))
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.Replace(s)
+		}
+		return fs.Set(f.Name, strings.Join(s, ","))
+	case map[string]any:
+		var pairs []string
+		for k, e := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, e))
+		}
+		sort.Strings(pairs)
+
+		// map flags are parsed as a line of csv
+		buf := &bytes.Buffer{}
+		w := csv.NewWriter(buf)
+		w.Write(pairs)
+		w.Flush()
+		return fs.Set(f.Name, strings.TrimSuffix(buf.String(), "\n"))
+	}
+	return fs.Set(f.Name, fmt.Sprint(value))
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2023 Matt Simons
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var kubeconfig string
+var kubeContext string
+var impersonateUser string
+var impersonateGroups []string
+var qps float32
+var burst int
+var requestTimeout time.Duration
+var clusters map[string]string
+
+func addClientFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&kubeconfig, "kubeconfig", "", "", "path to the kubeconfig file, defaults to $KUBECONFIG, ~/.kube/config or the in-cluster config")
+	fs.StringVarP(&kubeContext, "context", "", "", "name of the kubeconfig context to use")
+	fs.StringVarP(&impersonateUser, "as", "", "", "username to impersonate for every request")
+	fs.StringSliceVarP(&impersonateGroups, "as-group", "", nil, "group to impersonate for every request, can be repeated")
+	fs.Float32VarP(&qps, "qps", "", 20, "maximum queries per second to the API server")
+	fs.IntVarP(&burst, "burst", "", 30, "maximum burst of queries to the API server")
+	fs.StringToStringVarP(&clusters, "cluster", "", nil, "additional clusters named after a kubeconfig context which steps can switch to, e.g. --cluster east=east-context")
+	fs.DurationVarP(&requestTimeout, "request-timeout", "", 0, "how long to wait for a single request to the API server, 0 for no timeout")
+}
+
+// restConfig builds the rest.Config used for every request of the run from the client flags
+func restConfig() (*rest.Config, error) {
+	cfg, err := restConfigFor(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+	}
+	return cfg, nil
+}
+
+// restConfigFor builds a rest.Config for a kubeconfig context from the client flags
+func restConfigFor(context string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	overrides.AuthInfo.Impersonate = impersonateUser
+	overrides.AuthInfo.ImpersonateGroups = impersonateGroups
+
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.QPS = qps
+	cfg.Burst = burst
+	cfg.Timeout = requestTimeout
+	return cfg, nil
+}
+
+// clusterClients returns clients for each of the named clusters, they are created when
+// a step first switches to the cluster
+func clusterClients() (map[string]stepdef.ClientsFunc, error) {
+	named := map[string]stepdef.ClientsFunc{}
+	for name, context := range clusters {
+		if name == stepdef.DefaultCluster {
+			return nil, fmt.Errorf("the %s cluster is configured with --kubeconfig and --context", name)
+		}
+		name, context := name, context
+		named[name] = stepdef.LazyClients(func() (*rest.Config, error) {
+			cfg, err := restConfigFor(context)
+			if err != nil {
+				return nil, fmt.Errorf("could not load kubeconfig of cluster %s: %w", name, err)
+			}
+			return cfg, nil
+		})
+	}
+	return named, nil
+}
+
+// manifestSchemas returns the schemas manifests are validated against, the local schema
+// files if any are given, otherwise the OpenAPI schemas of the cluster
+func manifestSchemas() (stepdef.Schemas, error) {
+	if len(schemaFiles) > 0 {
+		return stepdef.LoadSchemas(schemaFiles)
+	}
+	cfg, err := restConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return stepdef.ClusterSchemas(client.OpenAPIV3()), nil
+}
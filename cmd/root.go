@@ -18,6 +18,7 @@ var rootCmd = &cobra.Command{
 
 func NewRootCommand() *cobra.Command {
 	rootCmd.PersistentFlags().StringSliceVarP(&plugins, "plugins", "p", []string{}, "Additional plugin step definitions")
+	addClientFlags(rootCmd.PersistentFlags())
 
 	rootCmd.AddCommand(NewTestCommand())
 	rootCmd.AddCommand(NewStepsCommand())
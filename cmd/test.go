@@ -6,6 +6,7 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"embed"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -24,7 +25,10 @@ import (
 	"github.com/spf13/viper"
 	"github.com/testernetes/bdk/model"
 	"github.com/testernetes/bdk/printers"
+	"github.com/testernetes/bdk/redact"
 	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -33,6 +37,17 @@ var format string
 var tags string
 var fastFail bool
 var debug bool
+var cleanupPolicy = stepdef.DefaultCleanupPolicy
+var cleanupPropagation string
+var collectDiagnostics bool
+var artifactsDir string
+var secretEnv []string
+var parallel int
+var scenarioTimeout time.Duration
+var vars map[string]string
+var fixturesDirs []string
+var validate bool
+var schemaFiles []string
 
 // testCmd represents running a test suite
 func NewTestCommand() *cobra.Command {
@@ -42,21 +57,50 @@ func NewTestCommand() *cobra.Command {
 		Long:  "",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			tagSettings, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			// the default timeout is registered first so that tag settings take precedence
+			model.Settings.Register(model.TagSettings{Timeout: scenarioTimeout})
+			model.Settings.Register(tagSettings...)
 
 			for _, p := range plugins {
 				plugin, err := plugin.Open(p)
 				if err != nil {
 					return err
 				}
-				v, err := plugin.Lookup("Step")
-				if err != nil {
-					return errors.New(fmt.Sprintf("could not find a variable called Step in %s", p))
+				v, stepErr := plugin.Lookup("Step")
+				if stepErr == nil {
+					step, ok := v.(*stepdef.StepDefinition)
+					if !ok {
+						return errors.New(fmt.Sprintf("expected Step in %s to be a scheme.StepDefinition however it was %T", p, v))
+					}
+					model.StepFunctions.Register(*step)
 				}
-				step, ok := v.(*stepdef.StepDefinition)
-				if !ok {
-					return errors.New(fmt.Sprintf("expected Step in %s to be a scheme.StepDefinition however it was %T", p, v))
+				v, hooksErr := plugin.Lookup("Hooks")
+				if hooksErr == nil {
+					hooks, ok := v.(*[]stepdef.Hook)
+					if !ok {
+						return errors.New(fmt.Sprintf("expected Hooks in %s to be a []stepdef.Hook however it was %T", p, v))
+					}
+					model.Hooks.Register(*hooks...)
 				}
-				model.StepFunctions.Register(*step)
+				v, fixturesErr := plugin.Lookup("Fixtures")
+				if fixturesErr == nil {
+					fixtures, ok := v.(*embed.FS)
+					if !ok {
+						return errors.New(fmt.Sprintf("expected Fixtures in %s to be an embed.FS however it was %T", p, v))
+					}
+					stepdef.RegisterFixtures(*fixtures)
+				}
+				if stepErr != nil && hooksErr != nil && fixturesErr != nil {
+					return errors.New(fmt.Sprintf("could not find a variable called Step, Hooks or Fixtures in %s", p))
+				}
+			}
+
+			if collectDiagnostics {
+				model.Hooks.Register(model.DiagnosticsHook(artifactsDir))
 			}
 
 			gomega.RegisterFailHandler(func(message string, _ ...int) {
@@ -112,6 +156,16 @@ func NewTestCommand() *cobra.Command {
 				}
 			}
 
+			if validate || len(schemaFiles) > 0 {
+				schemas, err := manifestSchemas()
+				if err != nil {
+					return fmt.Errorf("could not load schemas: %w", err)
+				}
+				if err := model.ValidateManifests(features, schemas); err != nil {
+					return fmt.Errorf("invalid manifests:\n%w", err)
+				}
+			}
+
 			ctx := context.Background()
 			c := make(chan os.Signal, 1)
 			signal.Notify(c, os.Interrupt)
@@ -136,21 +190,63 @@ func NewTestCommand() *cobra.Command {
 				}
 			}()
 
-			log.SetLogger(zap.New(zap.UseDevMode(debug), zap.WriteTo(os.Stderr)))
+			for _, name := range secretEnv {
+				if err := redact.Add(os.Getenv(name)); err != nil {
+					fmt.Fprintf(os.Stderr, "WARNING: the value of --secret-env %s will be printed: %s\n", name, err)
+				}
+			}
+
+			log.SetLogger(zap.New(zap.UseDevMode(debug), zap.WriteTo(redact.Writer(os.Stderr))))
 			ctx = log.IntoContext(ctx, log.Log.WithCallDepth(1))
 
+			// clients are shared by every step of the run, they are created when a step first
+			// needs them so that runs without cluster steps do not need a kubeconfig
+			ctx = stepdef.WithLazyClients(ctx, stepdef.LazyClients(restConfig))
+
+			named, err := clusterClients()
+			if err != nil {
+				return err
+			}
+			ctx = stepdef.WithLazyClusters(ctx, named)
+
+			cleanupPolicy.PropagationPolicy = metav1.DeletionPropagation(cleanupPropagation)
+			ctx = stepdef.WithCleanupPolicy(ctx, cleanupPolicy)
+			ctx = stepdef.WithFixturesDirs(ctx, fixturesDirs)
+
 			events := make(model.Events)
 			go printer.Print(events)
 
 			exitCode := 0
+
+			suiteCtx := store.NewScope(ctx, store.SuiteScope)
+			for k, v := range vars {
+				store.Save(suiteCtx, "scn-var-"+k, v)
+			}
+
+			_, suiteCleanups, err := model.Hooks.Run(suiteCtx, &events, stepdef.BeforeSuite, nil)
+			if err != nil {
+				fmt.Println(redact.String(err.Error())) // should be stderr
+				exitCode = 1
+				features = nil
+			}
+
+			// limits how many features run at once, every feature runs at once when parallel is 0
+			limit := parallel
+			if limit <= 0 {
+				limit = len(features)
+			}
+			running := make(chan struct{}, limit)
+
 			var wg sync.WaitGroup
 			for i := range features {
 				wg.Add(1)
+				running <- struct{}{}
 				go func(feature *model.Feature) {
 					defer wg.Done()
-					err := feature.Run(ctx, &events)
+					defer func() { <-running }()
+					err := feature.Run(suiteCtx, &events)
 					if err != nil {
-						fmt.Println(err.Error()) // should be stderr
+						fmt.Println(redact.String(err.Error())) // should be stderr
 						exitCode = 1
 						if fastFail {
 							cancel()
@@ -161,6 +257,16 @@ func NewTestCommand() *cobra.Command {
 
 			wg.Wait()
 
+			_, afterCleanups, err := model.Hooks.Run(suiteCtx, &events, stepdef.AfterSuite, nil)
+			for _, cleanup := range append(suiteCleanups, afterCleanups...) {
+				err = errors.Join(err, cleanup())
+			}
+			err = errors.Join(err, store.Close(suiteCtx))
+			if err != nil {
+				fmt.Println(redact.String(err.Error())) // should be stderr
+				exitCode = 1
+			}
+
 			events.Close()
 			signal.Stop(c)
 			cancel()
@@ -174,10 +280,26 @@ func NewTestCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&tags, "tags", "t", "", "tags to filter")
 	cmd.Flags().BoolVarP(&fastFail, "fast-fail", "", false, "stop testing on first failure")
 	cmd.Flags().BoolVarP(&debug, "debug", "D", false, "show debug logs")
+	cmd.Flags().BoolVarP(&cleanupPolicy.Wait, "cleanup-wait", "", false, "wait for created resources to be deleted during cleanup")
+	cmd.Flags().DurationVarP(&cleanupPolicy.Timeout, "cleanup-timeout", "", cleanupPolicy.Timeout, "how long to wait for a resource to be deleted before reporting it as stuck")
+	cmd.Flags().BoolVarP(&collectDiagnostics, "collect-diagnostics", "", true, "collect objects, events and pod logs from the cluster when a scenario fails")
+	cmd.Flags().StringVarP(&artifactsDir, "artifacts-dir", "", "", "directory to write failure diagnostics to")
+	cmd.Flags().StringVarP(&configFile, "config", "", "", "path to a bdk.yaml, by default it is searched for upward from the feature paths")
+	cmd.Flags().StringVarP(&profile, "profile", "", "", "name of a profile in the config file whose settings are used")
+	cmd.Flags().IntVarP(&parallel, "parallel", "", 0, "number of features to run at once, 0 runs every feature at once")
+	cmd.Flags().DurationVarP(&scenarioTimeout, "scenario-timeout", "", 0, "how long a scenario may run before it is cancelled, 0 for no timeout")
+	cmd.Flags().StringSliceVarP(&fixturesDirs, "fixtures-dir", "", nil, "directories searched for files given in steps which are not next to the feature file")
+	cmd.Flags().BoolVarP(&validate, "validate", "", false, "validate the manifests of every feature against the OpenAPI schemas of the cluster before running")
+	cmd.Flags().StringSliceVarP(&schemaFiles, "schemas", "", nil, "CRD or OpenAPI v3 files, directories or globs to validate manifests against instead of the cluster, implies --validate")
+	cmd.Flags().StringToStringVarP(&vars, "var", "", nil, "default variables for every scenario, e.g. --var registry=ghcr.io")
+	cmd.Flags().StringSliceVarP(&secretEnv, "secret-env", "", nil, "environment variables whose values are masked in output and logs")
+	cmd.Flags().StringVarP(&cleanupPropagation, "cleanup-propagation", "", "", "propagation policy used to delete resources during cleanup (Orphan|Background|Foreground), defaults to Foreground when waiting")
 
 	cmd.Flags().String("format-configmap-name", "results", "name of configmap to write results to")
 	viper.BindPFlag("format-configmap-name", cmd.Flags().Lookup("format-configmap-name"))
 	cmd.Flags().String("format-configmap-namespace", "default", "namespace of configmap to write results to")
 	viper.BindPFlag("format-configmap-namespace", cmd.Flags().Lookup("format-configmap-namespace"))
+	cmd.Flags().String("format-junit-attachments-dir", "attachments", "directory to write step attachments to when using the junit format")
+	viper.BindPFlag("format-junit-attachments-dir", cmd.Flags().Lookup("format-junit-attachments-dir"))
 	return cmd
 }
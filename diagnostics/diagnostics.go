@@ -0,0 +1,197 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/testernetes/bdk/redact"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Diagnostics is the state of the cluster relevant to a scenario
+type Diagnostics struct {
+	// Objects is the last known state of every object in the scenario's store
+	Objects map[string]*unstructured.Unstructured `json:"objects,omitempty"`
+	// Events are the events in every namespace an object is in
+	Events map[string][]corev1.Event `json:"events,omitempty"`
+	// Logs of every container of every pod in the store, previous containers
+	// are suffixed with .previous
+	Logs map[string]Log `json:"logs,omitempty"`
+	// Errors which occurred while collecting
+	Errors []string `json:"errors,omitempty"`
+}
+
+// MaxLogSize is the number of bytes at the end of each log which are kept when the
+// diagnostics are rendered as JSON, the full logs are written by WriteTo
+const MaxLogSize = 64 * 1024
+
+// Log is the output of a container
+type Log []byte
+
+// MarshalJSON renders the log as a string, long logs are truncated to their last
+// MaxLogSize bytes
+func (l Log) MarshalJSON() ([]byte, error) {
+	if len(l) <= MaxLogSize {
+		return json.Marshal(string(l))
+	}
+	return json.Marshal(fmt.Sprintf("... %d bytes truncated ...\n%s", len(l)-MaxLogSize, l[len(l)-MaxLogSize:]))
+}
+
+// Collect gathers diagnostics for everything referenced in the store
+func Collect(ctx context.Context, t *stepdef.T) *Diagnostics {
+	d := &Diagnostics{
+		Objects: map[string]*unstructured.Unstructured{},
+		Events:  map[string][]corev1.Event{},
+		Logs:    map[string]Log{},
+	}
+
+	namespaces := map[string]struct{}{}
+	for ref, value := range store.All(ctx) {
+		u, ok := value.(*unstructured.Unstructured)
+		if !ok || u == nil {
+			continue
+		}
+
+		latest := u.DeepCopy()
+		err := t.Client.Get(ctx, client.ObjectKeyFromObject(u), latest)
+		if err != nil {
+			// keep what is known about the object
+			latest = u.DeepCopy()
+			if !k8sErrors.IsNotFound(err) {
+				d.errorf("could not get %s: %s", ref, err)
+			}
+		}
+		if latest.GetKind() == "Secret" {
+			maskSecret(latest)
+		}
+		d.Objects[ref] = latest
+
+		if latest.GetNamespace() != "" {
+			namespaces[latest.GetNamespace()] = struct{}{}
+		}
+		if latest.GetKind() == "Namespace" {
+			namespaces[latest.GetName()] = struct{}{}
+		}
+		if latest.GetKind() == "Pod" {
+			d.collectLogs(ctx, t, latest)
+		}
+	}
+
+	for ns := range namespaces {
+		events, err := t.Clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			d.errorf("could not list events in %s: %s", ns, err)
+			continue
+		}
+		sort.Slice(events.Items, func(i, j int) bool {
+			return events.Items[i].LastTimestamp.Before(&events.Items[j].LastTimestamp)
+		})
+		d.Events[ns] = events.Items
+	}
+
+	return d
+}
+
+// maskSecret replaces the values of a Secret with redact.Mask, the values are also
+// masked wherever else they are printed or written
+func maskSecret(u *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		values, _, _ := unstructured.NestedMap(u.Object, field)
+		for k, v := range values {
+			if s, ok := v.(string); ok {
+				redact.AddEncoded(s)
+			}
+			values[k] = redact.Mask
+		}
+		if values != nil {
+			_ = unstructured.SetNestedMap(u.Object, values, field)
+		}
+	}
+}
+
+func (d *Diagnostics) collectLogs(ctx context.Context, t *stepdef.T, u *unstructured.Unstructured) {
+	pod := &corev1.Pod{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pod)
+	if err != nil {
+		d.errorf("could not convert %s to a pod: %s", u.GetName(), err)
+		return
+	}
+
+	restarted := map[string]bool{}
+	for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		restarted[s.Name] = s.RestartCount > 0
+	}
+
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		name := fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, c.Name)
+		d.collectLog(ctx, t, pod, name, &corev1.PodLogOptions{Container: c.Name})
+		if restarted[c.Name] {
+			d.collectLog(ctx, t, pod, name+".previous", &corev1.PodLogOptions{Container: c.Name, Previous: true})
+		}
+	}
+}
+
+func (d *Diagnostics) collectLog(ctx context.Context, t *stepdef.T, pod *corev1.Pod, name string, opts *corev1.PodLogOptions) {
+	logs, err := t.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+	if err != nil {
+		d.errorf("could not get logs for %s: %s", name, err)
+		return
+	}
+	d.Logs[name] = logs
+}
+
+func (d *Diagnostics) errorf(format string, a ...any) {
+	d.Errors = append(d.Errors, fmt.Sprintf(format, a...))
+}
+
+// WriteTo writes the diagnostics into dir as:
+//
+//	objects/<reference>.yaml
+//	events/<namespace>.yaml
+//	logs/<namespace>/<pod>/<container>[.previous].log
+//	errors.txt
+func (d *Diagnostics) WriteTo(dir string) (err error) {
+	for ref, o := range d.Objects {
+		err = errors.Join(err, writeYAML(filepath.Join(dir, "objects", ref+".yaml"), o.Object))
+	}
+	for ns, events := range d.Events {
+		err = errors.Join(err, writeYAML(filepath.Join(dir, "events", ns+".yaml"), events))
+	}
+	for name, logs := range d.Logs {
+		err = errors.Join(err, writeFile(filepath.Join(dir, "logs", filepath.FromSlash(name)+".log"), logs))
+	}
+	if len(d.Errors) > 0 {
+		err = errors.Join(err, writeFile(filepath.Join(dir, "errors.txt"), []byte(strings.Join(d.Errors, "\n")+"\n")))
+	}
+	return err
+}
+
+func writeYAML(path string, v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+func writeFile(path string, b []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,81 @@
+package diagnostics
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/redact"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Diagnostics", func() {
+	It("should write diagnostics to a directory", func() {
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName("example")
+		cm.SetNamespace("default")
+
+		d := &Diagnostics{
+			Objects: map[string]*unstructured.Unstructured{"cm": cm},
+			Events: map[string][]corev1.Event{
+				"default": {{ObjectMeta: metav1.ObjectMeta{Name: "example.1"}, Reason: "Created"}},
+			},
+			Logs: map[string]Log{
+				"default/app/server":          []byte("started\n"),
+				"default/app/server.previous": []byte("crashed\n"),
+			},
+			Errors: []string{"could not get pod: forbidden"},
+		}
+
+		dir := GinkgoT().TempDir()
+		Expect(d.WriteTo(dir)).Should(Succeed())
+
+		Expect(os.ReadFile(filepath.Join(dir, "objects", "cm.yaml"))).Should(ContainSubstring("name: example"))
+		Expect(os.ReadFile(filepath.Join(dir, "events", "default.yaml"))).Should(ContainSubstring("reason: Created"))
+		Expect(os.ReadFile(filepath.Join(dir, "logs", "default", "app", "server.log"))).Should(BeEquivalentTo("started\n"))
+		Expect(os.ReadFile(filepath.Join(dir, "logs", "default", "app", "server.previous.log"))).Should(BeEquivalentTo("crashed\n"))
+		Expect(os.ReadFile(filepath.Join(dir, "errors.txt"))).Should(ContainSubstring("forbidden"))
+	})
+
+	It("should include the end of the logs in JSON", func() {
+		long := strings.Repeat("x", MaxLogSize) + "crashed\n"
+		d := &Diagnostics{Logs: map[string]Log{
+			"default/app/server":          Log("started\n"),
+			"default/app/server.previous": Log(long),
+		}}
+
+		b, err := json.Marshal(d)
+		Expect(err).ShouldNot(HaveOccurred())
+		var out struct {
+			Logs map[string]string `json:"logs"`
+		}
+		Expect(json.Unmarshal(b, &out)).Should(Succeed())
+		Expect(out.Logs).Should(HaveKeyWithValue("default/app/server", "started\n"))
+		Expect(out.Logs["default/app/server.previous"]).Should(HavePrefix("... 8 bytes truncated ...\n"))
+		Expect(out.Logs["default/app/server.previous"]).Should(HaveSuffix("crashed\n"))
+	})
+
+	It("should mask the values of Secrets", func() {
+		DeferCleanup(redact.Reset)
+		secret := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]any{"name": "creds"},
+			"data":       map[string]any{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+			"stringData": map[string]any{"token": "s3cr3t-t0ken"},
+		}}
+
+		maskSecret(secret)
+		Expect(secret.Object["data"]).Should(Equal(map[string]any{"password": redact.Mask}))
+		Expect(secret.Object["stringData"]).Should(Equal(map[string]any{"token": redact.Mask}))
+		Expect(redact.String("hunter2 s3cr3t-t0ken")).Should(Equal(redact.Mask + " " + redact.Mask))
+	})
+})
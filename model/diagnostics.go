@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/testernetes/bdk/diagnostics"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+)
+
+// DiagnosticsHook returns an AfterScenario hook which collects diagnostics from the
+// cluster when a scenario fails. The diagnostics are attached to the scenario and
+// if artifactsDir is set written to a directory named after the scenario.
+func DiagnosticsHook(artifactsDir string) stepdef.Hook {
+	return stepdef.Hook{
+		Name: "collect failure diagnostics",
+		Type: stepdef.AfterScenario,
+		Function: func(ctx context.Context, t *stepdef.T) error {
+			scenario, err := store.Load[*Scenario](ctx, "scenario")
+			if err != nil {
+				return err
+			}
+			// there is nothing to collect if the run has no clients, e.g. without a kubeconfig
+			if scenario.Err == nil || t.Client == nil {
+				return nil
+			}
+
+			// the scenario may have been interrupted
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+			defer cancel()
+
+			scenario.Diagnostics = diagnostics.Collect(ctx, t)
+			if artifactsDir == "" {
+				return nil
+			}
+
+			dir := filepath.Join(artifactsDir, fmt.Sprintf("%s-%s", FileName(scenario.Name), stepdef.RandChars(5)))
+			t.Log.Info("writing diagnostics", "dir", dir)
+			return scenario.Diagnostics.WriteTo(dir)
+		},
+	}
+}
+
+// FileName replaces the characters of s which are not safe in a file name, e.g. to
+// name artifacts after a scenario
+func FileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') || r == '.' || r == '_' {
+			return r
+		}
+		return '-'
+	}, s)
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/redact"
 	"github.com/testernetes/bdk/stepdef"
 )
 
@@ -15,6 +16,8 @@ const (
 	StartStep      EventType = "StartStep"
 	FinishStep     EventType = "FinishStep"
 	InProgressStep EventType = "InProgressStep"
+	StartHook      EventType = "StartHook"
+	FinishHook     EventType = "FinishHook"
 )
 
 type Events chan Event
@@ -40,15 +43,23 @@ func (ch *Events) FinishScenario(scenario *Scenario) {
 }
 
 func (ch *Events) StartStep(step *messages.Step) {
-	*ch <- Event{Type: StartStep, Step: step}
+	*ch <- Event{Type: StartStep, Step: redact.Step(step)}
 }
 
 func (ch *Events) InProgressStep(step *messages.Step, result stepdef.StepResult) {
-	*ch <- Event{Type: InProgressStep, Step: step, StepResult: result}
+	*ch <- Event{Type: InProgressStep, Step: redact.Step(step), StepResult: redactResult(result)}
 }
 
 func (ch *Events) FinishStep(step *messages.Step, result stepdef.StepResult) {
-	*ch <- Event{Type: FinishStep, Step: step, StepResult: result}
+	*ch <- Event{Type: FinishStep, Step: redact.Step(step), StepResult: redactResult(result)}
+}
+
+func (ch *Events) StartHook(step *messages.Step) {
+	*ch <- Event{Type: StartHook, Step: redact.Step(step)}
+}
+
+func (ch *Events) FinishHook(step *messages.Step, result stepdef.StepResult) {
+	*ch <- Event{Type: FinishHook, Step: redact.Step(step), StepResult: redactResult(result)}
 }
 
 type Event struct {
@@ -59,3 +70,27 @@ type Event struct {
 	Step       *messages.Step
 	StepResult stepdef.StepResult
 }
+
+// redactResult returns a copy of the result with sensitive values masked in its
+// messages, error and attachments
+func redactResult(result stepdef.StepResult) stepdef.StepResult {
+	result.Err = redact.Error(result.Err)
+
+	if result.Messages != nil {
+		msgs := make([]string, len(result.Messages))
+		for i, m := range result.Messages {
+			msgs[i] = redact.String(m)
+		}
+		result.Messages = msgs
+	}
+
+	if result.Attachments != nil {
+		attachments := make([]stepdef.Attachment, len(result.Attachments))
+		for i, a := range result.Attachments {
+			a.Data = redact.Bytes(a.Data)
+			attachments[i] = a
+		}
+		result.Attachments = attachments
+	}
+	return result
+}
@@ -2,26 +2,55 @@ package model
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 
 	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
 )
 
 type Feature struct {
 	*messages.Feature
 	Path string `json:"string"`
 
-	//Rules []*Rule
 	Scenarios []*Scenario `json:"scenarios"` // or Scenario Outline
 }
 
+type jsonFeature struct {
+	URI         string      `json:"uri"`
+	Keyword     string      `json:"keyword"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Elements    []*Scenario `json:"elements"`
+}
+
+// MarshalJSON renders the feature similar to the Cucumber JSON format. Only the scenarios
+// are rendered, the Gherkin children would print steps without sensitive values masked.
+func (f *Feature) MarshalJSON() ([]byte, error) {
+	jf := jsonFeature{
+		URI:         f.Path,
+		Keyword:     f.Keyword,
+		Name:        f.Name,
+		Description: f.Description,
+		Elements:    f.Scenarios,
+	}
+	for _, t := range f.Tags {
+		jf.Tags = append(jf.Tags, t.Name)
+	}
+	if jf.Elements == nil {
+		jf.Elements = []*Scenario{}
+	}
+	return json.Marshal(jf)
+}
+
 func NewFeature(path string, featureDoc *messages.Feature, filters []Filter) (*Feature, error) {
 	f := &Feature{
 		Feature: featureDoc,
 		Path:    path,
 	}
-	var rules []*messages.Rule
 
 	var backgroundDoc *messages.Background
 	for _, fc := range featureDoc.Children {
@@ -35,78 +64,158 @@ func NewFeature(path string, featureDoc *messages.Feature, filters []Filter) (*F
 
 	for _, fc := range featureDoc.Children {
 		if fc.Rule != nil {
-			rules = append(rules, fc.Rule)
-		}
-		if fc.Scenario != nil {
-			scenarioTags := NewTags(append(featureDoc.Tags, fc.Scenario.Tags...))
-			if isFiltered(scenarioTags, filters) {
-				continue
+			ruleBackgroundDoc := backgroundDoc
+			var ruleScenarios []*messages.Scenario
+			for _, rc := range fc.Rule.Children {
+				if rc.Background != nil {
+					if ruleBackgroundDoc != backgroundDoc {
+						return f, errors.New("a rule can only have one background")
+					}
+					ruleBackgroundDoc = joinBackgrounds(backgroundDoc, rc.Background)
+				}
+				if rc.Scenario != nil {
+					ruleScenarios = append(ruleScenarios, rc.Scenario)
+				}
 			}
-
-			if len(fc.Scenario.Examples) == 0 {
-				s, err := NewScenario(backgroundDoc, fc.Scenario)
+			ruleTags := concatTags(featureDoc.Tags, fc.Rule.Tags)
+			for _, scenarioDoc := range ruleScenarios {
+				scenarios, err := newScenarios(ruleBackgroundDoc, scenarioDoc, NewTags(concatTags(ruleTags, scenarioDoc.Tags)), filters)
 				if err != nil {
 					return f, err
 				}
-				f.Scenarios = append(f.Scenarios, s)
+				for _, s := range scenarios {
+					s.Rule = fc.Rule
+				}
+				f.Scenarios = append(f.Scenarios, scenarios...)
 			}
+		}
+		if fc.Scenario != nil {
+			scenarios, err := newScenarios(backgroundDoc, fc.Scenario, NewTags(concatTags(featureDoc.Tags, fc.Scenario.Tags)), filters)
+			if err != nil {
+				return f, err
+			}
+			f.Scenarios = append(f.Scenarios, scenarios...)
+		}
+	}
 
-			for _, example := range fc.Scenario.Examples {
-				for _, r := range example.TableBody {
-					replacer := map[string]string{}
-					for i, v := range r.Cells {
-						key := "<" + example.TableHeader.Cells[i].Value + ">"
-						replacer[key] = v.Value
-					}
-					scn := deepCopyScenarioDoc(fc.Scenario)
-					for k, v := range replacer {
-						scn.Name = strings.ReplaceAll(scn.Name, k, v)
-						scn.Description = strings.ReplaceAll(scn.Description, k, v)
-						for _, s := range scn.Steps {
-							s.Text = strings.ReplaceAll(s.Text, k, v)
-							if s.DocString != nil {
-								s.DocString.Content = strings.ReplaceAll(s.DocString.Content, k, v)
-							}
-							if s.DataTable != nil {
-								for _, row := range s.DataTable.Rows {
-									for _, cell := range row.Cells {
-										cell.Value = strings.ReplaceAll(cell.Value, k, v)
-									}
-								}
+	if len(f.Scenarios) == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
 
+// newScenarios returns the scenario or a scenario for each row of its examples
+func newScenarios(backgroundDoc *messages.Background, scenarioDoc *messages.Scenario, scenarioTags []Tag, filters []Filter) (scenarios []*Scenario, err error) {
+	if isFiltered(scenarioTags, filters) {
+		return nil, nil
+	}
+
+	if len(scenarioDoc.Examples) == 0 {
+		s, err := NewScenario(backgroundDoc, scenarioDoc)
+		if err != nil {
+			return nil, err
+		}
+		s.tags = scenarioTags
+		scenarios = append(scenarios, s)
+	}
+
+	for _, example := range scenarioDoc.Examples {
+		for _, r := range example.TableBody {
+			replacer := map[string]string{}
+			for i, v := range r.Cells {
+				key := "<" + example.TableHeader.Cells[i].Value + ">"
+				replacer[key] = v.Value
+			}
+			scn := deepCopyScenarioDoc(scenarioDoc)
+			for k, v := range replacer {
+				scn.Name = strings.ReplaceAll(scn.Name, k, v)
+				scn.Description = strings.ReplaceAll(scn.Description, k, v)
+				for _, s := range scn.Steps {
+					s.Text = strings.ReplaceAll(s.Text, k, v)
+					if s.DocString != nil {
+						s.DocString.Content = strings.ReplaceAll(s.DocString.Content, k, v)
+					}
+					if s.DataTable != nil {
+						for _, row := range s.DataTable.Rows {
+							for _, cell := range row.Cells {
+								cell.Value = strings.ReplaceAll(cell.Value, k, v)
 							}
 						}
+
 					}
-					s, err := NewScenario(backgroundDoc, scn)
-					if err != nil {
-						return f, err
-					}
-					f.Scenarios = append(f.Scenarios, s)
 				}
 			}
+			s, err := NewScenario(backgroundDoc, scn)
+			if err != nil {
+				return nil, err
+			}
+			s.tags = scenarioTags
+			scenarios = append(scenarios, s)
 		}
 	}
+	return scenarios, nil
+}
 
-	//for _, RuleDoc := range rules {
-	//	s, err := NewRule(ruleDoc, scheme)
-	//	if err != nil {
-	//		return f, err
-	//	}
-	//	f.Rules = append(f.Rules, s)
-	//}
+func concatTags(a, b []*messages.Tag) []*messages.Tag {
+	return append(append([]*messages.Tag{}, a...), b...)
+}
 
-	if len(f.Scenarios) == 0 {
-		return nil, nil
+// joinBackgrounds returns a background with the steps of the feature's background
+// followed by the steps of the rule's background
+func joinBackgrounds(feature, rule *messages.Background) *messages.Background {
+	if feature == nil {
+		return rule
 	}
-	return f, nil
+	joined := *rule
+	joined.Steps = append(append([]*messages.Step{}, feature.Steps...), rule.Steps...)
+	return &joined
 }
 
-func (f *Feature) Run(ctx context.Context, events *Events) error {
+func (f *Feature) Run(ctx context.Context, events *Events) (errs error) {
 	events.StartFeature(f)
 	defer events.FinishFeature(f)
 
+	ctx = store.NewScope(ctx, store.FeatureScope)
+	ctx = stepdef.WithFeaturePath(ctx, f.Path)
+	defer func() {
+		errs = errors.Join(errs, store.Close(ctx))
+	}()
+
+	_, cleanups, err := Hooks.Run(ctx, events, stepdef.BeforeFeature, NewTags(f.Tags))
+	defer func() {
+		for _, cleanup := range cleanups {
+			errs = errors.Join(errs, cleanup())
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	// scenarios of a rule share a rule scope
+	var rule *messages.Rule
+	ruleCtx := ctx
+	defer func() {
+		if rule != nil {
+			errs = errors.Join(errs, store.Close(ruleCtx))
+		}
+	}()
+
 	for _, scenario := range f.Scenarios {
-		err := scenario.Run(ctx, events)
+		if scenario.Rule != rule {
+			if rule != nil {
+				err := store.Close(ruleCtx)
+				if err != nil {
+					return err
+				}
+			}
+			rule = scenario.Rule
+			ruleCtx = ctx
+			if rule != nil {
+				ruleCtx = store.NewScope(ctx, store.RuleScope)
+			}
+		}
+
+		err := scenario.Run(ruleCtx, events)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"strings"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Feature", func() {
+	It("should create scenarios for rules with the feature and rule backgrounds", func() {
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+Feature: rules
+  Background:
+    Given I set a to 1
+
+  Scenario: outside
+    Then I set b to 2
+
+  Rule: inside
+    Background:
+      Given I set c to 3
+
+    Scenario: first
+      Then I set d to 4
+
+    Scenario: second
+      Then I set e to 5
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		f, err := NewFeature("rules.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(f.Scenarios).Should(HaveLen(3))
+
+		Expect(f.Scenarios[0].Rule).Should(BeNil())
+		Expect(f.Scenarios[0].Background.Steps).Should(HaveLen(1))
+
+		for _, s := range f.Scenarios[1:] {
+			Expect(s.Rule).ShouldNot(BeNil())
+			Expect(s.Rule.Name).Should(Equal("inside"))
+			Expect(s.Background.Steps).Should(HaveLen(2))
+			Expect(s.Background.Steps[0].Text).Should(Equal("I set a to 1"))
+			Expect(s.Background.Steps[1].Text).Should(Equal("I set c to 3"))
+		}
+	})
+})
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+var ErrUnknownFunction = errors.New("unknown function")
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// substitutionFunc is a built-in function which can be called in step text, DocStrings
+// and DataTables as ${name(arg, ...)}. Arguments may contain ${var} references.
+type substitutionFunc func(args ...string) (string, error)
+
+var substitutionFuncs = map[string]substitutionFunc{
+	// ${uuid()} a random UUID
+	"uuid": func(args ...string) (string, error) {
+		if len(args) != 0 {
+			return "", fmt.Errorf("expected no arguments")
+		}
+		return string(uuid.NewUUID()), nil
+	},
+	// ${now()} the current time in RFC3339, optionally offset by a duration e.g. ${now(-30m)}
+	"now": func(args ...string) (string, error) {
+		now := time.Now().UTC()
+		switch len(args) {
+		case 0:
+		case 1:
+			offset, err := time.ParseDuration(args[0])
+			if err != nil {
+				return "", err
+			}
+			now = now.Add(offset)
+		default:
+			return "", fmt.Errorf("expected at most one offset")
+		}
+		return now.Format(time.RFC3339), nil
+	},
+	// ${base64(text)} base64 encodes text
+	"base64": func(args ...string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(strings.Join(args, ","))), nil
+	},
+	// ${base64decode(text)} base64 decodes text
+	"base64decode": func(args ...string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(strings.Join(args, ","))
+		return string(b), err
+	},
+	// ${randInt(min, max)} a random integer in [min, max)
+	"randInt": func(args ...string) (string, error) {
+		if len(args) != 2 {
+			return "", fmt.Errorf("expected a min and max")
+		}
+		min, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", err
+		}
+		max, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", err
+		}
+		if max <= min {
+			return "", fmt.Errorf("max must be greater than min")
+		}
+		return strconv.Itoa(min + rand.Intn(max-min)), nil
+	},
+	// ${randString(length)} random lowercase alphanumeric characters, optionally from
+	// the given charset e.g. ${randString(8, abcdef0123456789)}
+	"randString": func(args ...string) (string, error) {
+		if len(args) < 1 {
+			return "", fmt.Errorf("expected a length")
+		}
+		length, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", err
+		}
+		charset := []rune(alphanumeric)
+		if len(args) > 1 {
+			// the charset may itself contain commas
+			charset = []rune(strings.Join(args[1:], ","))
+		}
+		if length < 0 || len(charset) == 0 {
+			return "", fmt.Errorf("expected a non-negative length and a charset")
+		}
+		b := make([]rune, length)
+		for i := range b {
+			b[i] = charset[rand.Intn(len(charset))]
+		}
+		return string(b), nil
+	},
+	// ${lower(text)} lowercases text
+	"lower": func(args ...string) (string, error) {
+		return strings.ToLower(strings.Join(args, ",")), nil
+	},
+	// ${upper(text)} uppercases text
+	"upper": func(args ...string) (string, error) {
+		return strings.ToUpper(strings.Join(args, ",")), nil
+	},
+}
+
+// function calls cannot be nested but their arguments may contain ${var} references
+var funcCallRe = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9]*)\(((?:[^(){}$]|\$\{[^{}]*\}|\$)*)\)\}`)
+
+// substituteFunctions replaces each function call with a ${var} reference to its
+// result so that results are not themselves substituted. The arguments are
+// substituted with expand before the function is called.
+func substituteFunctions(s string, expand func(string) (string, error)) (string, map[string]string, error) {
+	results := map[string]string{}
+	var errs error
+	s = funcCallRe.ReplaceAllStringFunc(s, func(call string) string {
+		m := funcCallRe.FindStringSubmatch(call)
+		name, argStr := m[1], m[2]
+
+		f, ok := substitutionFuncs[name]
+		if !ok {
+			errs = errors.Join(errs, fmt.Errorf("%w: %s", ErrUnknownFunction, name))
+			return call
+		}
+
+		argStr, err := expand(argStr)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", name, err))
+			return call
+		}
+		var args []string
+		if strings.TrimSpace(argStr) != "" {
+			for _, arg := range strings.Split(argStr, ",") {
+				args = append(args, strings.TrimSpace(arg))
+			}
+		}
+
+		result, err := f(args...)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", name, err))
+			return call
+		}
+
+		key := fmt.Sprintf("__bdk_func_%d", len(results))
+		results[key] = result
+		return "${" + key + "}"
+	})
+	return s, results, errs
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"time"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+)
+
+var _ = Describe("Variable substitution", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = store.NewStoreFor(context.Background())
+		store.Save(ctx, "scn-var-name", "Bob")
+	})
+
+	DescribeTable("functions",
+		func(in string, match func(string)) {
+			out, err := variableSubstitution(ctx, in)
+			Expect(err).ShouldNot(HaveOccurred())
+			match(out)
+		},
+		Entry("substitutes variables", "hello ${name}", func(s string) {
+			Expect(s).Should(Equal("hello Bob"))
+		}),
+		Entry("uuid", "${uuid()}", func(s string) {
+			Expect(s).Should(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`))
+		}),
+		Entry("now", "${now()}", func(s string) {
+			t, err := time.Parse(time.RFC3339, s)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t).Should(BeTemporally("~", time.Now(), 2*time.Second))
+		}),
+		Entry("now with an offset", "${now(-1h)}", func(s string) {
+			t, err := time.Parse(time.RFC3339, s)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t).Should(BeTemporally("~", time.Now().Add(-time.Hour), 2*time.Second))
+		}),
+		Entry("base64 of a variable", "${base64(${name})}", func(s string) {
+			Expect(s).Should(Equal(base64.StdEncoding.EncodeToString([]byte("Bob"))))
+		}),
+		Entry("base64decode", "${base64decode(Qm9i)}", func(s string) {
+			Expect(s).Should(Equal("Bob"))
+		}),
+		Entry("randInt", "${randInt(5, 7)}", func(s string) {
+			Expect(s).Should(BeElementOf("5", "6"))
+		}),
+		Entry("randString", "${randString(6)}", func(s string) {
+			Expect(s).Should(MatchRegexp(`^[a-z0-9]{6}$`))
+		}),
+		Entry("randString with a charset", "${randString(10, ab)}", func(s string) {
+			Expect(s).Should(MatchRegexp(`^[ab]{10}$`))
+		}),
+		Entry("lower and upper", "${lower(${name})}-${upper(${name})}", func(s string) {
+			Expect(s).Should(Equal("bob-BOB"))
+		}),
+		Entry("does not substitute function results", "${base64decode(JHtuYW1lfQ==)}", func(s string) {
+			Expect(s).Should(Equal("${name}"))
+		}),
+	)
+
+	DescribeTable("invalid functions",
+		func(in string) {
+			_, err := variableSubstitution(ctx, in)
+			Expect(err).Should(HaveOccurred())
+		},
+		Entry("unknown function", "${nope()}"),
+		Entry("bad offset", "${now(tomorrow)}"),
+		Entry("bad range", "${randInt(7, 5)}"),
+	)
+
+	It("should substitute into a copy of every DataTable cell", func() {
+		var tables []*messages.DataTable
+		sf := stepFunctions{}
+		Expect(sf.register(stepdef.StepDefinition{
+			Name: "a-step",
+			Text: "^a step$",
+			Function: func(ctx context.Context, dt *messages.DataTable) error {
+				tables = append(tables, dt)
+				return nil
+			},
+			StepArg: stepdef.NewDataTableArgument("Table", "", "", func(ctx context.Context, dt *messages.DataTable, t reflect.Type) (reflect.Value, error) {
+				return reflect.ValueOf(dt), nil
+			}),
+		})).Should(Succeed())
+
+		// a background step is evaluated by every scenario
+		step := &messages.Step{
+			Text: "a step",
+			DataTable: &messages.DataTable{Rows: []*messages.TableRow{
+				{Cells: []*messages.TableCell{{Value: "name"}, {Value: "${name}"}}},
+				{Cells: []*messages.TableCell{{Value: "shout"}, {Value: "${upper(${name})}"}}},
+			}},
+		}
+		for _, name := range []string{"Bob", "Alice"} {
+			scenario := store.NewStoreFor(context.Background())
+			store.Save(scenario, "scn-var-name", name)
+			runner, err := sf.Eval(scenario, step, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			runner.Func.Call(runner.Args)
+		}
+
+		Expect(tables).Should(HaveLen(2))
+		Expect(tables[0].Rows[0].Cells[1].Value).Should(Equal("Bob"))
+		Expect(tables[0].Rows[1].Cells[1].Value).Should(Equal("BOB"))
+		Expect(tables[1].Rows[0].Cells[1].Value).Should(Equal("Alice"))
+		Expect(tables[1].Rows[1].Cells[1].Value).Should(Equal("ALICE"))
+		Expect(step.DataTable.Rows[0].Cells[1].Value).Should(Equal("${name}"))
+	})
+
+	It("should substitute into a copy of the text and DocString", func() {
+		var names, contents []string
+		sf := stepFunctions{}
+		Expect(sf.register(stepdef.StepDefinition{
+			Name: "greet",
+			Text: "^greet {text}$",
+			Function: func(ctx context.Context, name string, ds *messages.DocString) error {
+				names = append(names, name)
+				contents = append(contents, ds.Content)
+				return nil
+			},
+			StepArg: stepdef.MultiLineText,
+		})).Should(Succeed())
+
+		// a background step is evaluated by every scenario
+		step := &messages.Step{
+			Text:      "greet ${name}",
+			DocString: &messages.DocString{Content: "hello ${name}"},
+		}
+		for _, name := range []string{"Bob", "Alice"} {
+			scenario := store.NewStoreFor(context.Background())
+			store.Save(scenario, "scn-var-name", name)
+			runner, err := sf.Eval(scenario, step, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(runner.Step.Text).Should(Equal("greet " + name))
+			runner.Func.Call(runner.Args)
+		}
+
+		Expect(names).Should(Equal([]string{"Bob", "Alice"}))
+		Expect(contents).Should(Equal([]string{"hello Bob", "hello Alice"}))
+		Expect(step.Text).Should(Equal("greet ${name}"))
+		Expect(step.DocString.Content).Should(Equal("hello ${name}"))
+	})
+})
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+)
+
+var (
+	ErrHookMustHaveName     = errors.New("hook must have name")
+	ErrHookMustHaveFunction = errors.New("hook must have a function")
+	ErrUnknownHookType      = errors.New("unknown hook type")
+)
+
+type hook struct {
+	stepdef.Hook
+	filters []Filter
+}
+
+type hooks []hook
+
+var Hooks = &hooks{}
+
+type HookResult struct {
+	Type stepdef.HookType
+	Step *messages.Step
+	stepdef.StepResult
+}
+
+// return just an interface in future
+func (h *hooks) Register(hookDefs ...stepdef.Hook) {
+	for _, hd := range hookDefs {
+		err := h.register(hd)
+		if err != nil {
+			fmt.Printf("failed to register Hook %s\n", hd.Name)
+			panic(err)
+		}
+	}
+}
+
+func (h *hooks) register(hd stepdef.Hook) error {
+	if hd.Name == "" {
+		return ErrHookMustHaveName
+	}
+	if hd.Function == nil {
+		return ErrHookMustHaveFunction
+	}
+	if !slices.Contains(stepdef.HookTypes, hd.Type) {
+		return fmt.Errorf("%w: %s", ErrUnknownHookType, hd.Type)
+	}
+	*h = append(*h, hook{Hook: hd, filters: NewFilter(hd.Tags)})
+	return nil
+}
+
+// applies is true if the tags contain every tag the hook is for and none of the tags
+// it is against
+func (h *hook) applies(tags []Tag) bool {
+	if h.Type == stepdef.BeforeSuite || h.Type == stepdef.AfterSuite {
+		return true
+	}
+	return matches(tags, h.filters)
+}
+
+// Run runs all hooks of the given type which apply to the tags. Each hook is run
+// like a step, its progress and result are sent as events. The returned error
+// joins the errors of all hooks which did not pass.
+func (h *hooks) Run(ctx context.Context, events *Events, hookType stepdef.HookType, tags []Tag) (results []HookResult, cleanups []func() error, errs error) {
+	for i := range *h {
+		hk := &(*h)[i]
+		if hk.Type != hookType || !hk.applies(tags) {
+			continue
+		}
+
+		res, err := hk.run(ctx, events)
+		results = append(results, res)
+		cleanups = append(cleanups, res.Cleanup...)
+		if err == nil && res.Result != stepdef.Passed {
+			err = res.Err
+		}
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s hook %s: %w", hk.Type, hk.Name, err))
+		}
+	}
+	return
+}
+
+func (h *hook) run(ctx context.Context, events *Events) (HookResult, error) {
+	// hooks report progress against a step of their own, the step currently being
+	// run is restored afterwards so that AfterStep hooks do not replace it
+	step := &messages.Step{Keyword: string(h.Type) + " ", Text: h.Name}
+	previous, _ := store.Load[*messages.Step](ctx, "step")
+	store.Save(ctx, "step", step)
+	defer store.Save(ctx, "step", previous)
+
+	runner := &StepRunner{
+		Func:   reflect.ValueOf(h.Function),
+		Helper: stepdef.NewT(ctx, stepdef.StepDefinition{Name: h.Name}, events),
+	}
+	runner.Args = []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(runner.Helper)}
+
+	events.StartHook(step)
+	res, err := runner.Run()
+	// hooks are run whether or not there are clients, a hook which failed without them
+	// reports why, e.g. a missing kubeconfig, rather than only a nil pointer
+	if clientsErr := runner.Helper.ClientsErr(); clientsErr != nil && res.Result != stepdef.Passed {
+		res.Err = errors.Join(clientsErr, res.Err)
+		if err != nil {
+			err = errors.Join(clientsErr, err)
+		}
+	}
+	events.FinishHook(step, res)
+
+	return HookResult{Type: h.Type, Step: step, StepResult: res}, err
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var hookFunc = func(ctx context.Context, t *stepdef.T) error {
+	return nil
+}
+
+var _ = Describe("Hooks", func() {
+	DescribeTable("Registering hooks",
+		func(hd stepdef.Hook, m types.GomegaMatcher) {
+			var h hooks
+			Expect(h.register(hd)).Should(m)
+		},
+		Entry("should register a good hook", stepdef.Hook{Name: "good", Type: stepdef.BeforeScenario, Function: hookFunc}, Succeed()),
+		Entry("should not register a hook without a name", stepdef.Hook{Type: stepdef.BeforeScenario, Function: hookFunc}, MatchError(ErrHookMustHaveName)),
+		Entry("should not register a hook without a function", stepdef.Hook{Name: "no-func", Type: stepdef.BeforeScenario}, MatchError(ErrHookMustHaveFunction)),
+		Entry("should not register a hook of an unknown type", stepdef.Hook{Name: "unknown", Type: "BeforeLunch", Function: hookFunc}, MatchError(ErrUnknownHookType)),
+	)
+
+	DescribeTable("Filtering hooks by tags",
+		func(hd stepdef.Hook, tags []Tag, applies bool) {
+			var h hooks
+			Expect(h.register(hd)).Should(Succeed())
+			Expect(h[0].applies(tags)).Should(Equal(applies))
+		},
+		Entry("untagged hooks always apply", stepdef.Hook{Name: "any", Type: stepdef.AfterScenario, Function: hookFunc}, []Tag{{"db"}}, true),
+		Entry("tagged hooks apply to tagged scenarios", stepdef.Hook{Name: "db", Type: stepdef.AfterScenario, Tags: "@db", Function: hookFunc}, []Tag{{"db"}, {"slow"}}, true),
+		Entry("tagged hooks do not apply to other scenarios", stepdef.Hook{Name: "db", Type: stepdef.AfterScenario, Tags: "@db", Function: hookFunc}, []Tag{{"slow"}}, false),
+		Entry("hooks against a tag do not apply to scenarios with it", stepdef.Hook{Name: "fast", Type: stepdef.AfterScenario, Tags: "~@slow", Function: hookFunc}, []Tag{{"db"}, {"slow"}}, false),
+		Entry("hooks against a tag apply to scenarios without it", stepdef.Hook{Name: "fast", Type: stepdef.AfterScenario, Tags: "~@slow", Function: hookFunc}, []Tag{{"db"}}, true),
+		Entry("hooks for and against tags", stepdef.Hook{Name: "db", Type: stepdef.AfterScenario, Tags: "@db && ~@slow", Function: hookFunc}, []Tag{{"db"}}, true),
+		Entry("suite hooks ignore tags", stepdef.Hook{Name: "crds", Type: stepdef.BeforeSuite, Tags: "@db", Function: hookFunc}, nil, true),
+	)
+
+	Context("Running hooks without clients", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			noKubeconfig := func() (*stepdef.Clients, error) {
+				return nil, errors.New("could not load kubeconfig: no configuration has been provided")
+			}
+			ctx = store.NewScope(stepdef.WithLazyClients(context.Background(), noKubeconfig), store.SuiteScope)
+		})
+
+		run := func(f func(context.Context, *stepdef.T) error) error {
+			var h hooks
+			Expect(h.register(stepdef.Hook{Name: "hook", Type: stepdef.BeforeSuite, Function: f})).Should(Succeed())
+			events := make(Events, 2)
+			_, _, err := h.Run(ctx, &events, stepdef.BeforeSuite, nil)
+			return err
+		}
+
+		It("should report why there are no clients when a hook uses them", func() {
+			err := run(func(ctx context.Context, t *stepdef.T) error {
+				return t.Client.Get(ctx, client.ObjectKey{Name: "crds"}, &corev1.ConfigMap{})
+			})
+			Expect(err).Should(MatchError(ContainSubstring("could not load kubeconfig: no configuration has been provided")))
+		})
+
+		It("should run hooks which do not use clients", func() {
+			Expect(run(hookFunc)).Should(Succeed())
+		})
+	})
+})
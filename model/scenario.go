@@ -2,21 +2,100 @@ package model
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 
 	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/diagnostics"
+	"github.com/testernetes/bdk/redact"
 	"github.com/testernetes/bdk/stepdef"
 	"github.com/testernetes/bdk/store"
 )
 
 type Scenario struct {
 	*messages.Scenario
-	Background  *messages.Background `json:"background"`
+	Background *messages.Background `json:"background"`
+	// Rule the scenario belongs to, if any
+	Rule        *messages.Rule `json:"-"`
 	StepResults map[*messages.Step]stepdef.StepResult
+	// Err is the outcome of the scenario, it is set before AfterScenario hooks run
+	Err error
+	// Diagnostics collected from the cluster when the scenario failed
+	Diagnostics *diagnostics.Diagnostics
+	// HookResults are the results of BeforeScenario, AfterStep and AfterScenario hooks in the order they ran
+	HookResults []HookResult
+
+	tags []Tag
+}
+
+type jsonResult struct {
+	Status       string `json:"status"`
+	Duration     int64  `json:"duration,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+type jsonStep struct {
+	Keyword    string               `json:"keyword"`
+	Name       string               `json:"name"`
+	Result     jsonResult           `json:"result"`
+	Embeddings []stepdef.Attachment `json:"embeddings,omitempty"`
+}
+
+type jsonScenario struct {
+	Keyword     string                   `json:"keyword"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
+	Before      []jsonStep               `json:"before,omitempty"`
+	Steps       []jsonStep               `json:"steps"`
+	After       []jsonStep               `json:"after,omitempty"`
+	Diagnostics *diagnostics.Diagnostics `json:"diagnostics,omitempty"`
+}
+
+func newJSONStep(step *messages.Step, res stepdef.StepResult, ran bool) jsonStep {
+	step, res = redact.Step(step), redactResult(res)
+	js := jsonStep{
+		Keyword:    step.Keyword,
+		Name:       step.Text,
+		Result:     jsonResult{Status: stepdef.Skipped.String()},
+		Embeddings: res.Attachments,
+	}
+	if !ran {
+		return js
+	}
+	js.Result.Status = res.Result.String()
+	js.Result.Duration = res.EndTime.Sub(res.StartTime).Nanoseconds()
+	if res.Err != nil {
+		js.Result.ErrorMessage = res.Err.Error()
+	}
+	return js
 }
 
+// MarshalJSON renders the scenario and its results similar to the Cucumber JSON
+// format, attachments are rendered as embeddings.
 func (s *Scenario) MarshalJSON() ([]byte, error) {
-	return []byte(`"soon"`), nil
+	js := jsonScenario{
+		Keyword:     s.Keyword,
+		Name:        s.Name,
+		Description: s.Description,
+		Steps:       []jsonStep{},
+		Diagnostics: s.Diagnostics,
+	}
+	for _, t := range s.tags {
+		js.Tags = append(js.Tags, "@"+t.string)
+	}
+	for _, step := range append(append([]*messages.Step{}, s.Background.Steps...), s.Steps...) {
+		res, ran := s.StepResults[step]
+		js.Steps = append(js.Steps, newJSONStep(step, res, ran))
+	}
+	for _, h := range s.HookResults {
+		if h.Type == stepdef.BeforeScenario {
+			js.Before = append(js.Before, newJSONStep(h.Step, h.StepResult, true))
+			continue
+		}
+		js.After = append(js.After, newJSONStep(h.Step, h.StepResult, true))
+	}
+	return json.Marshal(js)
 }
 
 func NewScenario(bkg *messages.Background, scn *messages.Scenario) (*Scenario, error) {
@@ -31,7 +110,7 @@ func NewScenario(bkg *messages.Background, scn *messages.Scenario) (*Scenario, e
 	return s, nil
 }
 
-func (s *Scenario) Run(ctx context.Context, events *Events) error {
+func (s *Scenario) Run(ctx context.Context, events *Events) (errs error) {
 	events.StartScenario(s)
 	defer events.FinishScenario(s)
 
@@ -39,35 +118,71 @@ func (s *Scenario) Run(ctx context.Context, events *Events) error {
 
 	store.Save(ctx, "scenario", s)
 
+	ctx, cancel := Settings.apply(ctx, s.tags)
+	defer cancel()
+
 	var cleanups []func() error
-	var errs error
 	defer func() {
 		for _, cleanup := range cleanups {
-			errors.Join(errs, cleanup())
+			errs = errors.Join(errs, cleanup())
 		}
+		errs = errors.Join(errs, store.Close(ctx))
+	}()
+
+	defer func() {
+		s.Err = errs
+		results, hookCleanups, err := Hooks.Run(ctx, events, stepdef.AfterScenario, s.tags)
+		s.recordHooks(results)
+		cleanups = append(cleanups, hookCleanups...)
+		errs = errors.Join(errs, err)
+		s.Err = errs
 	}()
 
+	results, hookCleanups, err := Hooks.Run(ctx, events, stepdef.BeforeScenario, s.tags)
+	s.recordHooks(results)
+	cleanups = append(cleanups, hookCleanups...)
+	if err != nil {
+		return err
+	}
+
 	for _, step := range s.Background.Steps {
-		res, err := s.evalStep(ctx, events, step)
+		stepCleanups, err := s.runStep(ctx, events, step)
+		cleanups = append(cleanups, stepCleanups...)
 		if err != nil {
 			return err
 		}
-		s.StepResults[step] = res
-		cleanups = append(cleanups, res.Cleanup...)
 	}
 
 	for _, step := range s.Steps {
-		res, err := s.evalStep(ctx, events, step)
+		stepCleanups, err := s.runStep(ctx, events, step)
+		cleanups = append(cleanups, stepCleanups...)
 		if err != nil {
 			return err
 		}
-		s.StepResults[step] = res
-		cleanups = append(cleanups, res.Cleanup...)
 	}
 
 	return errs
 }
 
+// runStep evaluates the step followed by any AfterStep hooks
+func (s *Scenario) runStep(ctx context.Context, events *Events, step *messages.Step) (cleanups []func() error, err error) {
+	res, err := s.evalStep(ctx, events, step)
+	cleanups = append(cleanups, res.Cleanup...)
+
+	results, hookCleanups, hookErr := Hooks.Run(ctx, events, stepdef.AfterStep, s.tags)
+	s.recordHooks(results)
+	cleanups = append(cleanups, hookCleanups...)
+
+	return cleanups, errors.Join(err, hookErr)
+}
+
+func (s *Scenario) recordHooks(results []HookResult) {
+	for _, r := range results {
+		s.StepResults[r.Step] = r.StepResult
+	}
+	s.HookResults = append(s.HookResults, results...)
+}
+
 func (s *Scenario) evalStep(ctx context.Context, events *Events, step *messages.Step) (res stepdef.StepResult, err error) {
 	store.Save(ctx, "step", step)
 
@@ -75,8 +190,9 @@ func (s *Scenario) evalStep(ctx context.Context, events *Events, step *messages.
 	if err != nil {
 		return stepdef.StepResult{}, err
 	}
-	events.StartStep(step)
+	events.StartStep(stepFunction.Step)
 	res, err = stepFunction.Run()
-	events.FinishStep(step, res)
+	events.FinishStep(stepFunction.Step, res)
+	s.StepResults[step] = res
 	return
 }
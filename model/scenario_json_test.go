@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/redact"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+var _ = Describe("Scenario JSON", func() {
+	It("should render step results and attachments as embeddings", func() {
+		passing := &messages.Step{Keyword: "When ", Text: "I exec \"echo hello\" in pod"}
+		failing := &messages.Step{Keyword: "Then ", Text: "pod exec should say bye"}
+		skipped := &messages.Step{Keyword: "And ", Text: "I delete pod"}
+
+		s, err := NewScenario(nil, &messages.Scenario{Keyword: "Scenario", Name: "exec", Steps: []*messages.Step{passing, failing, skipped}})
+		Expect(err).ShouldNot(HaveOccurred())
+		s.StepResults[passing] = stepdef.StepResult{
+			Result:      stepdef.Passed,
+			Attachments: []stepdef.Attachment{{Name: "stdout", MediaType: "text/plain", Data: []byte("hello\n")}},
+		}
+		s.StepResults[failing] = stepdef.StepResult{Result: stepdef.Failed, Err: errors.New("did not say bye")}
+
+		b, err := json.Marshal(s)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(b).Should(MatchJSON(`{
+			"keyword": "Scenario",
+			"name": "exec",
+			"steps": [
+				{
+					"keyword": "When ",
+					"name": "I exec \"echo hello\" in pod",
+					"result": {"status": "passed"},
+					"embeddings": [{"name": "stdout", "mime_type": "text/plain", "data": "aGVsbG8K"}]
+				},
+				{
+					"keyword": "Then ",
+					"name": "pod exec should say bye",
+					"result": {"status": "failed", "error_message": "did not say bye"}
+				},
+				{
+					"keyword": "And ",
+					"name": "I delete pod",
+					"result": {"status": "skipped"}
+				}
+			]
+		}`))
+	})
+
+	It("should mask sensitive values", func() {
+		redact.Add("hunter2")
+		DeferCleanup(redact.Reset)
+
+		step := &messages.Step{Keyword: "Given ", Text: "I set token to hunter2"}
+		s, err := NewScenario(nil, &messages.Scenario{Keyword: "Scenario", Name: "secret", Steps: []*messages.Step{step}})
+		Expect(err).ShouldNot(HaveOccurred())
+		s.StepResults[step] = stepdef.StepResult{
+			Result:      stepdef.Failed,
+			Err:         errors.New("bad token hunter2"),
+			Attachments: []stepdef.Attachment{{Name: "stdout", MediaType: "text/plain", Data: []byte("hunter2")}},
+		}
+
+		b, err := json.Marshal(s)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(b).Should(MatchJSON(`{
+			"keyword": "Scenario",
+			"name": "secret",
+			"steps": [
+				{
+					"keyword": "Given ",
+					"name": "I set token to ******",
+					"result": {"status": "failed", "error_message": "bad token ******"},
+					"embeddings": [{"name": "stdout", "mime_type": "text/plain", "data": "KioqKioq"}]
+				}
+			]
+		}`))
+	})
+})
+
+var _ = Describe("Feature JSON", func() {
+	It("should render only the scenarios with sensitive values masked", func() {
+		redact.Add("hunter2")
+		DeferCleanup(redact.Reset)
+
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+@secrets
+Feature: secrets
+  Scenario: token
+    Given I set token to hunter2
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+		f, err := NewFeature("secrets.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		b, err := json.Marshal(f)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(b)).ShouldNot(ContainSubstring("hunter2"))
+		Expect(b).Should(MatchJSON(`{
+			"uri": "secrets.feature",
+			"keyword": "Feature",
+			"name": "secrets",
+			"tags": ["@secrets"],
+			"elements": [
+				{
+					"keyword": "Scenario",
+					"name": "token",
+					"tags": ["@secrets"],
+					"steps": [
+						{
+							"keyword": "Given ",
+							"name": "I set token to ******",
+							"result": {"status": "skipped"}
+						}
+					]
+				}
+			]
+		}`))
+	})
+})
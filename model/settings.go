@@ -0,0 +1,55 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/testernetes/bdk/store"
+)
+
+// TagSettings are applied to every scenario whose tags match Tags, a tag expression in
+// the same format as the --tags flag of bdk test. Settings without Tags apply to every
+// scenario. When several settings match, later ones take precedence.
+type TagSettings struct {
+	Tags string `mapstructure:"tags"`
+	// Timeout of the scenario, its context is cancelled once the timeout expires
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Vars are set before the scenario runs
+	Vars map[string]string `mapstructure:"vars"`
+}
+
+type tagSettings struct {
+	TagSettings
+	filters []Filter
+}
+
+type settings []tagSettings
+
+var Settings = &settings{}
+
+func (s *settings) Register(ts ...TagSettings) {
+	for _, t := range ts {
+		*s = append(*s, tagSettings{TagSettings: t, filters: NewFilter(t.Tags)})
+	}
+}
+
+// apply sets the vars of every matching setting in the scenario's store and returns
+// a context with the timeout of the last matching setting which has one
+func (s *settings) apply(ctx context.Context, tags []Tag) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	for _, ts := range *s {
+		if !matches(tags, ts.filters) {
+			continue
+		}
+		for k, v := range ts.Vars {
+			store.Save(ctx, "scn-var-"+k, v)
+		}
+		if ts.Timeout > 0 {
+			timeout = ts.Timeout
+		}
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
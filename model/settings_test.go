@@ -0,0 +1,88 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/store"
+)
+
+var _ = Describe("TagSettings", func() {
+	var s *settings
+
+	BeforeEach(func() {
+		s = &settings{}
+		s.Register(
+			TagSettings{Timeout: time.Minute, Vars: map[string]string{"replicas": "1", "image": "nginx"}},
+			TagSettings{Tags: "@slow", Timeout: time.Hour, Vars: map[string]string{"replicas": "3"}},
+		)
+	})
+
+	It("should apply settings without tags to every scenario", func() {
+		ctx, cancel := s.apply(store.NewStoreFor(context.Background()), []Tag{{"fast"}})
+		defer cancel()
+
+		Expect(store.Load[string](ctx, "scn-var-replicas")).Should(Equal("1"))
+		deadline, ok := ctx.Deadline()
+		Expect(ok).Should(BeTrue())
+		Expect(deadline).Should(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+	})
+
+	It("should let later matching settings take precedence", func() {
+		ctx, cancel := s.apply(store.NewStoreFor(context.Background()), []Tag{{"slow"}})
+		defer cancel()
+
+		Expect(store.Load[string](ctx, "scn-var-replicas")).Should(Equal("3"))
+		Expect(store.Load[string](ctx, "scn-var-image")).Should(Equal("nginx"))
+		deadline, ok := ctx.Deadline()
+		Expect(ok).Should(BeTrue())
+		Expect(deadline).Should(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+	})
+
+	It("should apply settings to scenarios with other tags as well", func() {
+		ctx, cancel := s.apply(store.NewStoreFor(context.Background()), []Tag{{"slow"}, {"db"}})
+		defer cancel()
+
+		Expect(store.Load[string](ctx, "scn-var-replicas")).Should(Equal("3"))
+	})
+
+	It("should apply settings to the scenarios of a tagged feature", func() {
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+@slow
+Feature: slow things
+  @db
+  Scenario: migrate
+    Given I set a to 1
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+		f, err := NewFeature("slow.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(f.Scenarios).Should(HaveLen(1))
+
+		ctx, cancel := s.apply(store.NewStoreFor(context.Background()), f.Scenarios[0].tags)
+		defer cancel()
+
+		Expect(store.Load[string](ctx, "scn-var-replicas")).Should(Equal("3"))
+	})
+
+	It("should not apply settings against a tag of the scenario", func() {
+		s.Register(TagSettings{Tags: "@slow && ~@db", Vars: map[string]string{"replicas": "5"}})
+		ctx, cancel := s.apply(store.NewStoreFor(context.Background()), []Tag{{"slow"}, {"db"}})
+		defer cancel()
+
+		Expect(store.Load[string](ctx, "scn-var-replicas")).Should(Equal("3"))
+	})
+
+	It("should not set a deadline without a timeout", func() {
+		ctx, cancel := (&settings{}).apply(store.NewStoreFor(context.Background()), nil)
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+		Expect(ok).Should(BeFalse())
+	})
+})
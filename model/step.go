@@ -7,6 +7,7 @@ import (
 	"runtime/debug"
 	"time"
 
+	messages "github.com/cucumber/messages/go/v21"
 	"github.com/testernetes/bdk/stepdef"
 )
 
@@ -14,6 +15,8 @@ type StepRunner struct {
 	Func   reflect.Value   `json:"-"`
 	Args   []reflect.Value `json:"-"`
 	Helper *stepdef.T
+	// Step is the step with its variables substituted
+	Step *messages.Step `json:"-"`
 }
 
 // Runs a Step Definition
@@ -30,6 +33,8 @@ func (s *StepRunner) Run() (result stepdef.StepResult, err error) {
 	defer func() {
 		endTime := time.Now()
 		if r := recover(); r != nil {
+			// keep any cleanups and attachments added before the panic
+			result = s.Helper.GetResult()
 			result.Err = errors.New(string(debug.Stack()))
 			result.StartTime = startTime
 			result.EndTime = endTime
@@ -13,6 +13,7 @@ import (
 	messages "github.com/cucumber/messages/go/v21"
 	"github.com/drone/envsubst"
 	"github.com/testernetes/bdk/printers/utils"
+	"github.com/testernetes/bdk/redact"
 	"github.com/testernetes/bdk/stepdef"
 	"github.com/testernetes/bdk/steps"
 	"github.com/testernetes/bdk/store"
@@ -37,10 +38,39 @@ var (
 func init() {
 	StepFunctions.Register(
 		steps.AResource,
+		steps.ResourcesFromKustomization,
+		steps.ResourcesFromHelmChartWithValues,
+		steps.ResourcesFromHelmChart,
 		steps.AResourceFromFile,
 		steps.APatch,
 		steps.ICreate,
+		steps.ICreateOncePer,
+		steps.ICreateInCluster,
+		steps.ICreateRejected,
+		steps.ICreateRejectedWithMessage,
+		steps.ICreateAllowed,
+		steps.ICreateAllowedWithWarning,
+		steps.AdmissionShould,
+		steps.IApply,
+		steps.IApplyAs,
+		steps.FieldShouldBeOwnedBy,
+		steps.ShouldBeManagedBy,
+		steps.IDryRunCreate,
+		steps.IDryRunUpdate,
+		steps.IDryRunPatch,
+		steps.IDryRunApply,
+		steps.ACluster,
+		steps.IAmUser,
+		steps.IAmUserInGroups,
+		steps.IAmServiceAccount,
+		steps.IStopImpersonating,
+		steps.IShouldBeForbidden,
+		steps.SubjectHasPermission,
+		steps.SubjectHasClusterPermission,
+		steps.SubjectHasPermissions,
+		steps.PermissionsShouldBe,
 		steps.ICreateATmpNamespace,
+		steps.ICreateATmpNamespaceOncePer,
 		steps.IDelete,
 		steps.IEvict,
 		steps.IExecInContainer,
@@ -49,8 +79,13 @@ func init() {
 		steps.IExecScriptInDefaultContainer,
 		steps.IGet,
 		steps.IPatch,
+		steps.IUpdate,
+		steps.IUpdateStatus,
+		steps.IReplace,
 		steps.IProxyGet,
 		steps.ISetVar,
+		steps.ISetScopedVar,
+		steps.ISetSecretVar,
 		steps.ISetVarFromJSONPath,
 		steps.AsyncAssertExec,
 		steps.AsyncAssertExecWithTimeout,
@@ -58,6 +93,8 @@ func init() {
 		steps.AsyncAssertLogWithTimeout,
 		steps.AsyncAssert,
 		steps.AsyncAssertWithTimeout,
+		steps.ShouldHaveCondition,
+		steps.ShouldHaveConditionWithTimeout,
 		steps.AsyncAssertResp,
 		steps.AsyncAssertRespWithTimeout,
 	)
@@ -119,33 +156,87 @@ func (sf *stepFunction) Matches(step *messages.Step) bool {
 // instanciate a stepdefinition given a step
 func (sf *stepFunction) Eval(ctx context.Context, step *messages.Step, events *Events) (*StepRunner, error) {
 	runner := &StepRunner{
-		Func:   sf.function,
-		Args:   []reflect.Value{reflect.ValueOf(ctx)},
-		Helper: stepdef.NewT(ctx, sf.StepDefinition, events),
+		Func: sf.function,
 	}
 
 	argOffset := 1 // ctx
 	tFunc := sf.function.Type()
 
-	targetType := tFunc.In(argOffset)
-	if targetType == reflect.TypeOf(&stepdef.T{}) {
-		runner.Args = append(runner.Args, reflect.ValueOf(runner.Helper))
+	takesT := tFunc.In(argOffset) == reflect.TypeOf(&stepdef.T{})
+	if takesT {
 		argOffset += 1
 	}
 
 	captureGroups := sf.re.FindStringSubmatch(step.Text)[1:]
+
+	cluster, err := sf.cluster(ctx, captureGroups)
+	if err != nil {
+		return nil, err
+	}
+	ctx = stepdef.WithCluster(ctx, cluster)
+
+	runner.Helper = stepdef.NewT(ctx, sf.StepDefinition, events)
+	runner.Args = []reflect.Value{reflect.ValueOf(ctx)}
+	if takesT {
+		clients, err := stepdef.ClientsFor(ctx, cluster)
+		if err != nil {
+			return nil, fmt.Errorf("could not create clients for the %s cluster: %w", cluster, err)
+		}
+		if id, ok := stepdef.IdentityFrom(ctx); ok {
+			if _, err := clients.Impersonate(id); err != nil {
+				return nil, fmt.Errorf("could not impersonate %s: %w", id, err)
+			}
+		}
+		runner.Args = append(runner.Args, reflect.ValueOf(runner.Helper))
+	}
+
 	var matchedSoFar string
+	var fanOut *resourceSetArg
 	for i, p := range sf.parameters {
 		value := captureGroups[i]
 		targetType := tFunc.In(argOffset + i)
 
-		arg, err := p.Parse(ctx, value, targetType)
-		if err != nil {
+		// secrets are masked before the step is printed by any event
+		if p.Name() == "{secret}" {
+			if err := redact.Add(value); err != nil {
+				log.FromContext(ctx).Info("WARNING: the secret will be printed", "step", step.Text, "reason", err.Error())
+			}
+		}
+
+		// steps acting on a resource set act on each of its members
+		if set, ok := resourceSet(ctx, p, value); ok && takesT {
+			if fanOut != nil {
+				return nil, fmt.Errorf("only one resource set can be referred to by a step, %s and %s are sets", fanOut.name, value)
+			}
+			fanOut, err = newResourceSetArg(ctx, p, value, set, targetType)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %s => ???%s???: %w", i, value, targetType.String(), err)
+			}
+			fanOut.index = len(runner.Args)
+			for _, member := range fanOut.members {
+				if _, known := stepdef.ClusterOf(ctx, member); !known || sf.hasParameter("{cluster}") {
+					stepdef.SetClusterOf(ctx, member, cluster)
+				}
+			}
+		}
+
+		var arg reflect.Value
+		if fanOut != nil && fanOut.index == len(runner.Args) {
+			// replaced by each member when the step runs
+			arg = fanOut.args[0]
+		} else if arg, err = p.Parse(ctx, value, targetType); err != nil {
 			fmt.Printf(matchedSoFar)
 			return nil, fmt.Errorf("[%d]: %s => ???%s???: %w", i, value, targetType.String(), err)
 		}
 		matchedSoFar = fmt.Sprintf("%s[%d]: %s => %s\n", matchedSoFar, i, value, arg)
 		runner.Args = append(runner.Args, arg)
+
+		// the resources a step acts on belong to its cluster
+		if takesT && p.Name() == "{reference}" {
+			if _, known := stepdef.ClusterOf(ctx, value); !known || sf.hasParameter("{cluster}") {
+				stepdef.SetClusterOf(ctx, value, cluster)
+			}
+		}
 	}
 
 	if sf.StepArg.StepArgType() != stepdef.NoStepArgType {
@@ -157,42 +248,193 @@ func (sf *stepFunction) Eval(ctx context.Context, step *messages.Step, events *E
 		runner.Args = append(runner.Args, arg)
 	}
 
+	if fanOut != nil {
+		runner.Func = fanOut.wrap(sf.function)
+	}
 	return runner, nil
 }
 
+// resourceSetArg is a reference to a resource set, the step is called once for each member
+type resourceSetArg struct {
+	name    string
+	index   int
+	members []string
+	args    []reflect.Value
+}
+
+func resourceSet(ctx context.Context, p stepdef.StringParameter, value string) (stepdef.ResourceSet, bool) {
+	if p.Name() != "{reference}" {
+		return nil, false
+	}
+	set, err := store.Load[stepdef.ResourceSet](ctx, value)
+	return set, err == nil
+}
+
+func newResourceSetArg(ctx context.Context, p stepdef.StringParameter, name string, set stepdef.ResourceSet, targetType reflect.Type) (*resourceSetArg, error) {
+	fanOut := &resourceSetArg{name: name, members: set.Members(name)}
+	for _, member := range fanOut.members {
+		arg, err := p.Parse(ctx, member, targetType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", member, err)
+		}
+		fanOut.args = append(fanOut.args, arg)
+	}
+	return fanOut, nil
+}
+
+// wrap returns a function which calls f for each member and joins the errors
+func (r *resourceSetArg) wrap(f reflect.Value) reflect.Value {
+	return reflect.MakeFunc(f.Type(), func(in []reflect.Value) []reflect.Value {
+		var errs []error
+		for i, arg := range r.args {
+			args := append([]reflect.Value{}, in...)
+			args[r.index] = arg
+			out := f.Call(args)
+			if err, ok := out[0].Interface().(error); ok && err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", r.members[i], err))
+			}
+		}
+		err := reflect.New(f.Type().Out(0)).Elem()
+		if joined := errors.Join(errs...); joined != nil {
+			err.Set(reflect.ValueOf(joined))
+		}
+		return []reflect.Value{err}
+	})
+}
+
+// cluster returns the cluster the step runs against: the cluster named in the step,
+// otherwise the cluster of the resources it refers to, otherwise the active cluster
+func (sf *stepFunction) cluster(ctx context.Context, values []string) (string, error) {
+	var cluster, clusterRef string
+	for i, p := range sf.parameters {
+		switch p.Name() {
+		case "{cluster}":
+			return values[i], nil
+		case "{reference}":
+			c, known := stepdef.ClusterOf(ctx, values[i])
+			if !known {
+				continue
+			}
+			if cluster != "" && c != cluster {
+				return "", fmt.Errorf("%s is in the %s cluster but %s is in the %s cluster", clusterRef, cluster, values[i], c)
+			}
+			cluster, clusterRef = c, values[i]
+		}
+	}
+	if cluster == "" {
+		cluster = stepdef.ClusterFrom(ctx)
+	}
+	return cluster, nil
+}
+
+func (sf *stepFunction) hasParameter(name string) bool {
+	for _, p := range sf.parameters {
+		if p.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 type stepFunctions []stepFunction
 
 var StepFunctions = &stepFunctions{}
 
 func (s *stepFunctions) Eval(ctx context.Context, step *messages.Step, events *Events) (*StepRunner, error) {
+	// background steps are shared by every scenario of a feature so variables are
+	// substituted into a copy
+	substituted := *step
+
 	text, err := variableSubstitution(ctx, step.Text)
 	if err != nil {
 		return nil, fmt.Errorf("step text: could not substitute variables: %w", err)
 	}
 	if text != "" {
-		step.Text = text
+		substituted.Text = text
 	}
 
-	if step.DocString != nil {
+	// templates have their own syntax for variables and are rendered when parsed
+	if step.DocString != nil && !stepdef.IsTemplate(step.DocString) {
 		ds, err := variableSubstitution(ctx, step.DocString.Content)
 		if err != nil {
 			return nil, fmt.Errorf("docstring: could not substitute variables: %w", err)
 		}
 		if ds != "" {
-			step.DocString.Content = ds
+			docString := *step.DocString
+			docString.Content = ds
+			substituted.DocString = &docString
 		}
 	}
 
-	// TODO datatable replacement
+	if step.DataTable != nil {
+		dt, err := substituteDataTable(ctx, step.DataTable)
+		if err != nil {
+			return nil, fmt.Errorf("datatable: could not substitute variables: %w", err)
+		}
+		substituted.DataTable = dt
+	}
+	step = &substituted
+	// the step's events report the substituted step
+	store.Save(ctx, "step", step)
+
+	// a step starting with "as user ..." or "as serviceaccount ..." impersonates for just that step
+	if id, rest, ok := asIdentity(step.Text); ok {
+		impersonated := *step
+		impersonated.Text = rest
+		step = &impersonated
+		ctx = stepdef.WithIdentity(ctx, id)
+	}
 
 	for _, sf := range *s {
 		if sf.Matches(step) {
 			log.FromContext(ctx).V(1).Info(sf.re.String())
-			return sf.Eval(ctx, step, events)
+			runner, err := sf.Eval(ctx, step, events)
+			if err != nil {
+				return nil, err
+			}
+			runner.Step = &substituted
+			return runner, nil
 		}
 	}
 
-	return nil, fmt.Errorf("could not find a matching step definition for: %s", text)
+	return nil, fmt.Errorf("could not find a matching step definition for: %s", substituted.Text)
+}
+
+// substituteDataTable returns a copy of the table with variables substituted in every cell
+func substituteDataTable(ctx context.Context, dt *messages.DataTable) (*messages.DataTable, error) {
+	out := &messages.DataTable{Location: dt.Location, Rows: make([]*messages.TableRow, len(dt.Rows))}
+	for i, row := range dt.Rows {
+		out.Rows[i] = &messages.TableRow{Location: row.Location, Id: row.Id, Cells: make([]*messages.TableCell, len(row.Cells))}
+		for j, cell := range row.Cells {
+			v, err := variableSubstitution(ctx, cell.Value)
+			if err != nil {
+				return nil, err
+			}
+			out.Rows[i].Cells[j] = &messages.TableCell{Location: cell.Location, Value: v}
+		}
+	}
+	return out, nil
+}
+
+var asIdentityRe = regexp.MustCompile(`^as (?:user ` + stepdef.UserName + `(?: in groups ` + stepdef.GroupNames + `)?|serviceaccount ` + stepdef.ServiceAccountRef + `) (.+)$`)
+
+// asIdentity splits a step starting with "as user <name> [in groups <groups>]" or
+// "as serviceaccount <namespace>/<name>" into the identity and the rest of the step
+func asIdentity(text string) (stepdef.Identity, string, bool) {
+	m := asIdentityRe.FindStringSubmatch(text)
+	if m == nil {
+		return stepdef.Identity{}, text, false
+	}
+	user, groups, sa, rest := m[1], m[2], m[3], m[4]
+	if sa != "" {
+		namespace, name, _ := strings.Cut(sa, "/")
+		return stepdef.ServiceAccount(namespace, name), rest, true
+	}
+	id := stepdef.Identity{User: user}
+	if groups != "" {
+		id.Groups = strings.Split(groups, ",")
+	}
+	return id, rest, true
 }
 
 // return just an interface in future
@@ -305,9 +547,12 @@ func validateFunction(vFunc reflect.Value) error {
 	return nil
 }
 
+// variableSubstitution substitutes ${var} references with scenario variables or
+// environment variables and calls built-in functions such as ${uuid()}. A
+// reference made of only X's, e.g. ${XXXXX}, is replaced with as many random characters.
 func variableSubstitution(ctx context.Context, s string) (string, error) {
-	return envsubst.Eval(s, func(key string) string {
-		val := store.Load[string](ctx, "scn-var-"+key)
+	mapping := func(key string) string {
+		val, _ := store.Load[string](ctx, "scn-var-"+key)
 		if val == "" {
 			val = os.Getenv(key)
 		}
@@ -317,5 +562,19 @@ func variableSubstitution(ctx context.Context, s string) (string, error) {
 			}
 		}
 		return val
+	}
+
+	s, results, err := substituteFunctions(s, func(args string) (string, error) {
+		return envsubst.Eval(args, mapping)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return envsubst.Eval(s, func(key string) string {
+		if result, ok := results[key]; ok {
+			return result
+		}
+		return mapping(key)
 	})
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/onsi/gomega/types"
 	"github.com/testernetes/bdk/stepdef"
 	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
 )
 
 var GoodStep = stepdef.StepDefinition{
@@ -118,4 +120,109 @@ var _ = Describe("StepFunctions", func() {
 		})
 
 	})
+
+	Context("Impersonating in a step", func() {
+		var sf stepFunctions
+
+		BeforeEach(func() {
+			sf = stepFunctions{}
+			Expect(sf.register(GoodStep)).Should(Succeed())
+		})
+
+		identityOf := func(text string) (stepdef.Identity, bool) {
+			ctx := store.NewStoreFor(context.Background())
+			step := &messages.Step{Text: text}
+			store.Save(ctx, "step", step)
+			runner, err := sf.Eval(ctx, step, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(runner.Args[1].Interface()).Should(Equal("step"))
+			return stepdef.IdentityFrom(runner.Args[0].Interface().(context.Context))
+		}
+
+		It("should impersonate a user and groups", func() {
+			id, ok := identityOf("as user bob@example.com in groups dev,qa a step")
+			Expect(ok).Should(BeTrue())
+			Expect(id).Should(Equal(stepdef.Identity{User: "bob@example.com", Groups: []string{"dev", "qa"}}))
+		})
+
+		It("should impersonate a service account", func() {
+			id, ok := identityOf("as serviceaccount team-a/builder a step")
+			Expect(ok).Should(BeTrue())
+			Expect(id).Should(Equal(stepdef.ServiceAccount("team-a", "builder")))
+			Expect(id.User).Should(Equal("system:serviceaccount:team-a:builder"))
+		})
+
+		It("should not impersonate otherwise", func() {
+			_, ok := identityOf("a step")
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	Context("Referring to a resource set", func() {
+		var sf stepFunctions
+		var called []string
+
+		BeforeEach(func() {
+			called = nil
+			sf = stepFunctions{}
+			Expect(sf.register(stepdef.StepDefinition{
+				Name: "i-touch",
+				Text: "^I touch {reference}$",
+				Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured) error {
+					called = append(called, ref.GetName())
+					if ref.GetName() == "bad" {
+						return fmt.Errorf("cannot touch")
+					}
+					return nil
+				},
+				StepArg: stepdef.NoStepArg,
+			})).Should(Succeed())
+		})
+
+		run := func(text string) error {
+			ctx := stepdef.WithClients(store.NewStoreFor(context.Background()), &stepdef.Clients{Clientset: &kubernetes.Clientset{}})
+			set := stepdef.ResourceSet{}
+			for _, name := range []string{"a", "bad", "c"} {
+				obj := &unstructured.Unstructured{}
+				obj.SetAPIVersion("v1")
+				obj.SetKind("ConfigMap")
+				obj.SetName(name)
+				set = append(set, obj)
+			}
+			Expect(stepdef.SaveResourceSet(ctx, "app", set)).Should(Succeed())
+
+			runner, err := sf.Eval(ctx, &messages.Step{Text: text}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			out := runner.Func.Call(runner.Args)
+			err, _ = out[0].Interface().(error)
+			return err
+		}
+
+		It("should call the step for each member", func() {
+			err := run("I touch app")
+			Expect(called).Should(Equal([]string{"a", "bad", "c"}))
+			Expect(err).Should(MatchError("app/configmap/bad: cannot touch"))
+		})
+
+		It("should refer to a single member", func() {
+			Expect(run("I touch app/configmap/c")).Should(Succeed())
+			Expect(called).Should(Equal([]string{"c"}))
+		})
+	})
+
+	DescribeTable("Matching the registered steps",
+		func(text, name string) {
+			var matched []string
+			for _, sf := range *StepFunctions {
+				if sf.Matches(&messages.Step{Text: text}) {
+					matched = append(matched, sf.Name)
+				}
+			}
+			Expect(matched).Should(Equal([]string{name}))
+		},
+		Entry("resources from a kustomization", "resources from kustomization ./overlays/test", "resources-from-kustomization"),
+		Entry("a resource from a file named kustomization", "a app from kustomization.yaml", "a-resource-from-file"),
+		Entry("a resource from a file in a kustomization directory", "a app from kustomization/app.yaml", "a-resource-from-file"),
+		Entry("resources from a helm chart", "resources from helm chart ./charts/app", "resources-from-helm-chart"),
+	)
 })
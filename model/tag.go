@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"strings"
 
 	messages "github.com/cucumber/messages/go/v21"
@@ -36,6 +37,17 @@ func isFiltered(tags []Tag, filter []Filter) bool {
 	return false
 }
 
+// matches is true if the tags contain every tag the filters are for and none of the tags
+// they are against
+func matches(tags []Tag, filters []Filter) bool {
+	for _, f := range filters {
+		if f.bool != slices.Contains(tags, Tag{f.string}) {
+			return false
+		}
+	}
+	return true
+}
+
 func NewTags(in []*messages.Tag) (out []Tag) {
 	for _, t := range in {
 		out = append(out, Tag{t.Name[1:]})
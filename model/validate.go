@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+// ValidateManifests validates the manifests in the DocStrings of every step of the features
+// against the schemas, so that unknown fields and wrong types are reported with the line of
+// the feature file before any step runs. Templates and DocStrings with ${variables} are only
+// known once the scenario runs and are not validated. Custom resources are also validated
+// against the CustomResourceDefinitions created by the features.
+func ValidateManifests(features []*Feature, schemas stepdef.Schemas) error {
+	type manifest struct {
+		path string
+		ds   *messages.DocString
+	}
+	var manifests []manifest
+	var contents [][]byte
+	validated := map[*messages.DocString]bool{}
+	for _, feature := range features {
+		for _, scenario := range feature.Scenarios {
+			for _, step := range append(append([]*messages.Step{}, scenario.Background.Steps...), scenario.Steps...) {
+				ds := step.DocString
+				if ds == nil || validated[ds] || !StepFunctions.takesManifest(step) {
+					continue
+				}
+				validated[ds] = true
+				if strings.HasSuffix(ds.MediaType, stepdef.TemplateSuffix) || strings.Contains(ds.Content, "${") {
+					continue
+				}
+				manifests = append(manifests, manifest{path: feature.Path, ds: ds})
+				contents = append(contents, []byte(ds.Content))
+			}
+		}
+	}
+
+	schemas, err := stepdef.WithCRDs(schemas, contents...)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, m := range manifests {
+		fieldErrs, err := stepdef.ValidateManifest(contents[i], schemas)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", m.path, m.ds.Location.Line, err))
+			continue
+		}
+		for _, fieldErr := range fieldErrs {
+			// the first line of the content is the line after the opening delimiter
+			errs = append(errs, fmt.Errorf("%s:%d: %w", m.path, int(m.ds.Location.Line)+fieldErr.Line, fieldErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// takesManifest is true if the step matches a step definition whose DocString is a manifest
+func (s *stepFunctions) takesManifest(step *messages.Step) bool {
+	text := step.Text
+	if _, rest, ok := asIdentity(text); ok {
+		text = rest
+	}
+	for _, sf := range *s {
+		if sf.re.MatchString(text) {
+			return sf.StepArg.Name() == stepdef.Manifest.Name()
+		}
+	}
+	return false
+}
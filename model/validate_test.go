@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strings"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/client-go/openapi/openapitest"
+)
+
+var _ = Describe("ValidateManifests", func() {
+	It("should report invalid fields with the line of the feature file", func() {
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+Feature: manifests
+  Background:
+    Given a resource called cm
+      """
+      apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: example
+      date:
+        foo: bar
+      """
+
+  Scenario: first
+    Given a resource called templated
+      """
+      apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: ${NAME}
+      oops: true
+      """
+    And as user bob a resource called pod
+      """
+      apiVersion: v1
+      kind: Pod
+      metadata:
+        name: example
+      spec:
+        containers: app
+      """
+
+  Scenario: second
+    Given I set a to 1
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+		f, err := NewFeature("manifests.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = ValidateManifests([]*Feature{f}, stepdef.ClusterSchemas(openapitest.NewEmbeddedFileClient()))
+		Expect(err).Should(MatchError(`manifests.feature:10: .date: unknown field, did you mean "data"?
+manifests.feature:30: .spec.containers: expected an array but found the string "app"`))
+	})
+
+	It("should validate custom resources against the CustomResourceDefinitions of the features", func() {
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+Feature: widgets
+  Scenario: install widgets
+    Given a resource called crd
+      """
+      apiVersion: apiextensions.k8s.io/v1
+      kind: CustomResourceDefinition
+      metadata:
+        name: widgets.example.com
+      spec:
+        group: example.com
+        names:
+          kind: Widget
+          plural: widgets
+        scope: Namespaced
+        versions:
+        - name: v1
+          served: true
+          storage: true
+          schema:
+            openAPIV3Schema:
+              type: object
+              properties:
+                spec:
+                  type: object
+                  properties:
+                    size:
+                      type: integer
+      """
+    And a resource called widget
+      """
+      apiVersion: example.com/v1
+      kind: Widget
+      metadata:
+        name: example
+      spec:
+        size: 3
+      """
+    When I create crd
+    And I create widget
+
+  Scenario: use widgets
+    Given a resource called widget
+      """
+      apiVersion: example.com/v1
+      kind: Widget
+      metadata:
+        name: example
+      spec:
+        colour: red
+      """
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+		f, err := NewFeature("widgets.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = ValidateManifests([]*Feature{f}, stepdef.ClusterSchemas(openapitest.NewEmbeddedFileClient()))
+		Expect(err).Should(MatchError(`widgets.feature:50: .spec.colour: unknown field`))
+	})
+})
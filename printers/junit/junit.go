@@ -0,0 +1,150 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	messages "github.com/cucumber/messages/go/v21"
+	"github.com/spf13/viper"
+	"github.com/testernetes/bdk/model"
+	"github.com/testernetes/bdk/redact"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+type testSuites struct {
+	XMLName xml.Name    `xml:"testsuites"`
+	Suites  []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Time     float64    `xml:"time,attr"`
+	Cases    []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+// Printer prints a JUnit XML report. Step attachments are written as files to the
+// format-junit-attachments-dir and referenced from system-out as [[ATTACHMENT|path]].
+type Printer struct{}
+
+func (p Printer) Print(events model.Events) {
+	report := testSuites{}
+	for {
+		event, more := <-events
+		if !more {
+			out, err := xml.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Println(err)
+			}
+			fmt.Printf("%s%s\n", xml.Header, out)
+			return
+		}
+
+		switch event.Type {
+		case model.FinishFeature:
+			report.Suites = append(report.Suites, p.suite(event.Feature))
+		}
+	}
+}
+
+func (p Printer) suite(feature *model.Feature) testSuite {
+	suite := testSuite{Name: feature.Name}
+	for _, scenario := range feature.Scenarios {
+		tc := p.testCase(feature, scenario)
+		suite.Tests++
+		suite.Time += tc.Time
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return suite
+}
+
+func (p Printer) testCase(feature *model.Feature, scenario *model.Scenario) testCase {
+	tc := testCase{
+		Name:      scenario.Name,
+		ClassName: feature.Name,
+	}
+	if scenario.Err != nil {
+		msg := redact.String(scenario.Err.Error())
+		tc.Failure = &failure{Message: firstLine(msg), Content: msg}
+	}
+
+	out := &strings.Builder{}
+	var start, end time.Time
+	steps := append(append([]*messages.Step{}, scenario.Background.Steps...), scenario.Steps...)
+	for _, step := range steps {
+		res, ran := scenario.StepResults[step]
+		if !ran {
+			fmt.Fprintf(out, "%s%s ... %s\n", step.Keyword, redact.String(step.Text), stepdef.Skipped)
+			continue
+		}
+		if start.IsZero() {
+			start = res.StartTime
+		}
+		end = res.EndTime
+		fmt.Fprintf(out, "%s%s ... %s\n", step.Keyword, redact.String(step.Text), res.Result)
+		for _, a := range res.Attachments {
+			path, err := p.writeAttachment(scenario, a)
+			if err != nil {
+				fmt.Fprintf(out, "could not write attachment %s: %s\n", a.Name, err)
+				continue
+			}
+			fmt.Fprintf(out, "[[ATTACHMENT|%s]]\n", path)
+		}
+	}
+	tc.SystemOut = out.String()
+	tc.Time = end.Sub(start).Seconds()
+	return tc
+}
+
+func (p Printer) writeAttachment(scenario *model.Scenario, a stepdef.Attachment) (string, error) {
+	dir := filepath.Join(viper.GetString("format-junit-attachments-dir"), model.FileName(scenario.Name))
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, model.FileName(a.Name)+"-*"+extension(a.MediaType))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	_, err = f.Write(redact.Bytes(a.Data))
+	return f.Name(), err
+}
+
+func extension(mediaType string) string {
+	switch {
+	case strings.HasSuffix(mediaType, "yaml"):
+		return ".yaml"
+	case strings.HasSuffix(mediaType, "json"):
+		return ".json"
+	case strings.HasPrefix(mediaType, "text/"):
+		return ".txt"
+	}
+	return ""
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,65 @@
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"github.com/testernetes/bdk/model"
+	"github.com/testernetes/bdk/redact"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+var _ = Describe("JUnit", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		viper.Set("format-junit-attachments-dir", dir)
+		DeferCleanup(func() { viper.Set("format-junit-attachments-dir", nil) })
+		redact.Add("hunter2")
+		DeferCleanup(redact.Reset)
+	})
+
+	It("should render a failed scenario with its attachments", func() {
+		passing := &messages.Step{Keyword: "When ", Text: "I exec \"echo hello\" in pod"}
+		failing := &messages.Step{Keyword: "Then ", Text: "pod exec should say bye"}
+		skipped := &messages.Step{Keyword: "And ", Text: "I delete pod"}
+
+		s, err := model.NewScenario(nil, &messages.Scenario{Keyword: "Scenario", Name: "exec in pod", Steps: []*messages.Step{passing, failing, skipped}})
+		Expect(err).ShouldNot(HaveOccurred())
+		start := time.Now()
+		s.StepResults[passing] = stepdef.StepResult{
+			Result:      stepdef.Passed,
+			StartTime:   start,
+			EndTime:     start.Add(time.Second),
+			Attachments: []stepdef.Attachment{{Name: "stdout", MediaType: "text/plain", Data: []byte("hello hunter2\n")}},
+		}
+		s.StepResults[failing] = stepdef.StepResult{Result: stepdef.Failed, StartTime: start.Add(time.Second), EndTime: start.Add(3 * time.Second)}
+		s.Err = errors.New("did not say bye\nstdout: hello")
+
+		feature := &model.Feature{Feature: &messages.Feature{Name: "pods"}, Scenarios: []*model.Scenario{s}}
+		out, err := xml.MarshalIndent(testSuites{Suites: []testSuite{Printer{}.suite(feature)}}, "", "  ")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		attachments, err := filepath.Glob(filepath.Join(dir, "exec-in-pod", "stdout-*.txt"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(attachments).Should(HaveLen(1))
+		Expect(os.ReadFile(attachments[0])).Should(BeEquivalentTo("hello ******\n"))
+
+		Expect(string(out)).Should(Equal(`<testsuites>
+  <testsuite name="pods" tests="1" failures="1" time="3">
+    <testcase name="exec in pod" classname="pods" time="3">
+      <failure message="did not say bye">did not say bye&#xA;stdout: hello</failure>
+      <system-out>When I exec &#34;echo hello&#34; in pod ... passed&#xA;[[ATTACHMENT|` + attachments[0] + `]]&#xA;Then pod exec should say bye ... failed&#xA;And I delete pod ... skipped&#xA;</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>`))
+	})
+})
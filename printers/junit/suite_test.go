@@ -0,0 +1,13 @@
+package junit
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestJUnit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "junit printer suite")
+}
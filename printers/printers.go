@@ -5,12 +5,14 @@ import (
 
 	"github.com/testernetes/bdk/model"
 	"github.com/testernetes/bdk/printers/json"
+	"github.com/testernetes/bdk/printers/junit"
 	"github.com/testernetes/bdk/printers/simple"
 )
 
 var Printers = map[string]Printer{
 	"simple": &simple.Printer{},
 	"json":   &json.Printer{},
+	"junit":  &junit.Printer{},
 	//"configmap": &configmap.Printer{},
 	//"debug":     &debug.Printer{},
 }
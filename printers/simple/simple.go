@@ -72,6 +72,11 @@ func (p Printer) Print(events model.Events) {
 			cursor.StartOfLine()
 		case model.FinishStep:
 			p.step(event.Step, event.StepResult)
+		case model.FinishHook:
+			p.clear()
+			if event.StepResult.Result != stepdef.Passed {
+				p.step(event.Step, event.StepResult)
+			}
 		}
 	}
 }
@@ -108,6 +113,21 @@ func (p Printer) step(step *messages.Step, result stepdef.StepResult) {
 		utils.NewNormalizer(result.Err.Error()).Indent(3).Println()
 	}
 	color.Unset()
+
+	for _, a := range result.Attachments {
+		attachment(a)
+	}
+}
+
+func attachment(a stepdef.Attachment) {
+	if len(a.Data) == 0 {
+		return
+	}
+	if !utils.IsText(a.MediaType) {
+		utils.NewNormalizer("%s (%s, %d bytes)", a.Name, a.MediaType, len(a.Data)).Indent(3).Println()
+		return
+	}
+	utils.NewNormalizer("%s", strings.TrimSuffix(string(a.Data), "\n")).Snippet(a.Name).IndentTabs(3).Println()
 }
 
 func maxColLengths(t *messages.DataTable) []int {
@@ -0,0 +1,64 @@
+package simple_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/model"
+	"github.com/testernetes/bdk/printers/simple"
+	"github.com/testernetes/bdk/redact"
+)
+
+var _ = Describe("Simple printer", func() {
+	// stdout returns what f prints
+	stdout := func(f func()) string {
+		r, w, err := os.Pipe()
+		Expect(err).ShouldNot(HaveOccurred())
+		orig := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = orig }()
+
+		out := make(chan string)
+		go func() {
+			b, _ := io.ReadAll(r)
+			out <- string(b)
+		}()
+		f()
+		w.Close()
+		return <-out
+	}
+
+	It("should never print secrets", func() {
+		GinkgoT().Setenv("BDK_TEST_TOKEN", "s3cr3t-t0ken")
+		DeferCleanup(redact.Reset)
+
+		doc, err := gherkin.ParseGherkinDocument(strings.NewReader(`
+Feature: secrets
+  Scenario: token
+    Given I set secret var token to ${BDK_TEST_TOKEN}
+    And I set header to Bearer ${token}
+`), (&messages.Incrementing{}).NewId)
+		Expect(err).ShouldNot(HaveOccurred())
+		f, err := model.NewFeature("secrets.feature", doc.Feature, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		printed := stdout(func() {
+			events := make(model.Events)
+			go func() {
+				defer events.Close()
+				Expect(f.Run(context.Background(), &events)).Should(Succeed())
+			}()
+			(&simple.Printer{}).Print(events)
+		})
+
+		Expect(printed).Should(ContainSubstring("I set secret var token to " + redact.Mask))
+		Expect(printed).Should(ContainSubstring("I set header to Bearer " + redact.Mask))
+		Expect(printed).ShouldNot(ContainSubstring("s3cr3t-t0ken"))
+	})
+})
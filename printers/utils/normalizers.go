@@ -129,3 +129,11 @@ func (s normalizer) IndentTabs(by int) Normalizer {
 	s.string = strings.Join(indentedLines, "\n") // + "\n"
 	return s
 }
+
+// IsText returns true if data of the media type can be printed
+func IsText(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "yaml") ||
+		strings.HasSuffix(mediaType, "xml")
+}
@@ -0,0 +1,165 @@
+// Package redact masks sensitive values, such as tokens and the contents of
+// Secrets, in text which is printed or logged.
+package redact
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	messages "github.com/cucumber/messages/go/v21"
+)
+
+// Mask replaces sensitive values
+const Mask = "******"
+
+// MinLength is the length a value must have to be masked, shorter values would mask
+// too much unrelated text
+const MinLength = 4
+
+// ErrTooShort is returned by Add for values which are too short to be masked
+var ErrTooShort = fmt.Errorf("sensitive values shorter than %d characters cannot be masked", MinLength)
+
+var sensitive = &values{set: map[string]struct{}{}}
+
+type values struct {
+	lock     sync.RWMutex
+	set      map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// Add marks values as sensitive for the rest of the run. ErrTooShort is returned if a
+// value is too short to be masked, it is printed as it is.
+func Add(vals ...string) (err error) {
+	sensitive.lock.Lock()
+	defer sensitive.lock.Unlock()
+	for _, v := range vals {
+		if len(v) < MinLength {
+			if v != "" {
+				err = ErrTooShort
+			}
+			continue
+		}
+		sensitive.set[v] = struct{}{}
+	}
+
+	// longest first so that a value which contains another is masked whole
+	var sorted []string
+	for v := range sensitive.set {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	var oldnew []string
+	for _, v := range sorted {
+		oldnew = append(oldnew, v, Mask)
+	}
+	sensitive.replacer = strings.NewReplacer(oldnew...)
+	return err
+}
+
+// AddEncoded marks a value which may be base64 encoded, like the data of a Secret,
+// as sensitive along with its decoded value
+func AddEncoded(v string) {
+	// the data of a Secret is masked as a whole when it is printed, short values are
+	// left to that
+	_ = Add(v)
+	if decoded, err := base64.StdEncoding.DecodeString(v); err == nil {
+		_ = Add(string(decoded))
+	}
+}
+
+// Reset forgets all sensitive values
+func Reset() {
+	sensitive.lock.Lock()
+	defer sensitive.lock.Unlock()
+	sensitive.set = map[string]struct{}{}
+	sensitive.replacer = nil
+}
+
+// String masks every sensitive value in s
+func String(s string) string {
+	sensitive.lock.RLock()
+	defer sensitive.lock.RUnlock()
+	if sensitive.replacer == nil {
+		return s
+	}
+	return sensitive.replacer.Replace(s)
+}
+
+// Bytes masks every sensitive value in b
+func Bytes(b []byte) []byte {
+	return []byte(String(string(b)))
+}
+
+// Error returns an error whose message has sensitive values masked, it unwraps to err
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Step returns a copy of the step with sensitive values masked in its text,
+// DocString and DataTable
+func Step(step *messages.Step) *messages.Step {
+	if step == nil {
+		return nil
+	}
+	s := *step
+	s.Text = String(step.Text)
+	if step.DocString != nil {
+		ds := *step.DocString
+		ds.Content = String(ds.Content)
+		s.DocString = &ds
+	}
+	if step.DataTable != nil {
+		dt := *step.DataTable
+		dt.Rows = make([]*messages.TableRow, len(step.DataTable.Rows))
+		for i, row := range step.DataTable.Rows {
+			r := *row
+			r.Cells = make([]*messages.TableCell, len(row.Cells))
+			for j, cell := range row.Cells {
+				c := *cell
+				c.Value = String(c.Value)
+				r.Cells[j] = &c
+			}
+			dt.Rows[i] = &r
+		}
+		s.DataTable = &dt
+	}
+	return &s
+}
+
+// Writer returns a writer which masks sensitive values before writing to w. Values
+// are only masked if they are written in a single call to Write.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	_, err := w.w.Write(Bytes(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,80 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redact", func() {
+	BeforeEach(func() {
+		Reset()
+		DeferCleanup(Reset)
+	})
+
+	It("should not change text when there are no sensitive values", func() {
+		Expect(String("my token is hunter2")).Should(Equal("my token is hunter2"))
+	})
+
+	It("should mask sensitive values", func() {
+		Expect(Add("hunter2")).Should(Succeed())
+		Expect(String("my token is hunter2, not abc")).Should(Equal("my token is ******, not abc"))
+	})
+
+	It("should report values which are too short to be masked", func() {
+		Expect(Add("hunter2", "abc")).Should(MatchError(ErrTooShort))
+		Expect(String("my token is hunter2, not abc")).Should(Equal("my token is ******, not abc"))
+		Expect(Add("")).Should(Succeed())
+	})
+
+	It("should mask the longest value first", func() {
+		Add("secret", "secretvalue")
+		Expect(String("secretvalue")).Should(Equal(Mask))
+	})
+
+	It("should mask encoded values and their decoded value", func() {
+		AddEncoded(base64.StdEncoding.EncodeToString([]byte("hunter2")))
+		Expect(String("aHVudGVyMg== hunter2")).Should(Equal("****** ******"))
+	})
+
+	It("should mask errors and keep the chain", func() {
+		Add("hunter2")
+		cause := errors.New("cause")
+		err := Error(errors.Join(errors.New("bad token hunter2"), cause))
+		Expect(err.Error()).Should(ContainSubstring("bad token ******"))
+		Expect(err).Should(MatchError(cause))
+		Expect(Error(nil)).Should(BeNil())
+	})
+
+	It("should mask a copy of a step", func() {
+		Add("hunter2")
+		step := &messages.Step{
+			Text:      "I set token to hunter2",
+			DocString: &messages.DocString{Content: "token: hunter2"},
+			DataTable: &messages.DataTable{Rows: []*messages.TableRow{
+				{Cells: []*messages.TableCell{{Value: "token"}, {Value: "hunter2"}}},
+			}},
+		}
+		masked := Step(step)
+		Expect(masked.Text).Should(Equal("I set token to ******"))
+		Expect(masked.DocString.Content).Should(Equal("token: ******"))
+		Expect(masked.DataTable.Rows[0].Cells[1].Value).Should(Equal(Mask))
+
+		Expect(step.Text).Should(Equal("I set token to hunter2"))
+		Expect(step.DocString.Content).Should(Equal("token: hunter2"))
+		Expect(step.DataTable.Rows[0].Cells[1].Value).Should(Equal("hunter2"))
+	})
+
+	It("should mask written text", func() {
+		Add("hunter2")
+		buf := &bytes.Buffer{}
+		n, err := Writer(buf).Write([]byte("token=hunter2"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).Should(Equal(len("token=hunter2")))
+		Expect(buf.String()).Should(Equal("token=******"))
+	})
+})
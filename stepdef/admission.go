@@ -0,0 +1,109 @@
+package stepdef
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Admission is the outcome of a request which admission or validation may reject
+type Admission struct {
+	Allowed  bool                 `json:"allowed"`
+	Code     int32                `json:"code,omitempty"`
+	Reason   metav1.StatusReason  `json:"reason,omitempty"`
+	Message  string               `json:"message,omitempty"`
+	Causes   []metav1.StatusCause `json:"causes,omitempty"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+// NewAdmission returns the admission of a request from its error and the warnings of
+// its response. Errors which are not an API status, such as connection errors, are
+// returned as they do not tell whether the request was admitted.
+func NewAdmission(err error, warnings []string) (*Admission, error) {
+	admission := &Admission{Allowed: err == nil, Warnings: warnings}
+	if err == nil {
+		return admission, nil
+	}
+
+	var apiStatus k8sErrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return nil, err
+	}
+	status := apiStatus.Status()
+	admission.Code = status.Code
+	admission.Reason = status.Reason
+	admission.Message = status.Message
+	if status.Details != nil {
+		admission.Causes = status.Details.Causes
+	}
+	return admission, nil
+}
+
+func (a *Admission) String() string {
+	if a.Allowed {
+		return "allowed"
+	}
+	return fmt.Sprintf("rejected (%s): %s", a.Reason, a.Message)
+}
+
+// Object returns the admission as an object which jsonpaths can be evaluated against
+func (a *Admission) Object() (map[string]any, error) {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	o := map[string]any{}
+	return o, json.Unmarshal(b, &o)
+}
+
+// Warnings records the warnings which the API server sends in response headers
+type Warnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+var _ rest.WarningHandler = &Warnings{}
+
+func (w *Warnings) HandleWarningHeader(code int, agent string, message string) {
+	if code != 299 || message == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, message)
+}
+
+// Messages returns the warnings in the order they were received
+func (w *Warnings) Messages() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.messages...)
+}
+
+// WithWarnings returns a client like Client which passes the warnings of its
+// responses to the handler instead of logging them
+func (c *Clients) WithWarnings(handler rest.WarningHandler) (client.WithWatch, error) {
+	cfg := rest.CopyConfig(c.Config)
+	cfg.WarningHandler = handler
+	return client.NewWithWatch(cfg, client.Options{
+		HTTPClient:     c.httpClient,
+		Scheme:         c.Scheme,
+		Mapper:         c.Mapper,
+		WarningHandler: client.WarningHandlerOptions{SuppressWarnings: true},
+	})
+}
+
+// WithWarnings returns a client like t.Client which passes the warnings of its
+// responses to the handler
+func (t *T) WithWarnings(handler rest.WarningHandler) (client.WithWatch, error) {
+	if t.clients == nil {
+		return nil, fmt.Errorf("no clients for the %s cluster", t.Cluster)
+	}
+	return t.clients.WithWarnings(handler)
+}
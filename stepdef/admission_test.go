@@ -0,0 +1,73 @@
+package stepdef_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Admission", func() {
+	It("should record a rejection", func() {
+		invalid := k8sErrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "example", field.ErrorList{
+			field.Invalid(field.NewPath("data", "replicas"), "-1", "must be positive"),
+		})
+		admission, err := stepdef.NewAdmission(invalid, []string{"deprecated"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(admission.Allowed).Should(BeFalse())
+		Expect(admission.Code).Should(BeEquivalentTo(http.StatusUnprocessableEntity))
+		Expect(admission.Reason).Should(Equal(metav1.StatusReasonInvalid))
+		Expect(admission.Message).Should(ContainSubstring("must be positive"))
+		Expect(admission.Causes).Should(HaveLen(1))
+		Expect(admission.Warnings).Should(ConsistOf("deprecated"))
+
+		o, err := admission.Object()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(stepdef.NewHaveJSONPathMatcher("{.causes[0].field}", Equal("data.replicas")).Match(o)).Should(BeTrue())
+	})
+
+	It("should record an allowed request", func() {
+		admission, err := stepdef.NewAdmission(nil, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(admission.Allowed).Should(BeTrue())
+		Expect(admission.String()).Should(Equal("allowed"))
+	})
+
+	It("should not record errors which are not an API status", func() {
+		_, err := stepdef.NewAdmission(errors.New("connection refused"), nil)
+		Expect(err).Should(MatchError("connection refused"))
+	})
+
+	It("should capture the warnings of responses", func() {
+		api := apiServer()
+		defer api.Close()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Warning", `299 - "example is deprecated"`)
+			api.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		clients, err := stepdef.NewClients(&rest.Config{Host: server.URL})
+		Expect(err).ShouldNot(HaveOccurred())
+		ctx := stepdef.WithClients(store.NewStoreFor(context.Background()), clients)
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+
+		warnings := &stepdef.Warnings{}
+		c, err := t.WithWarnings(warnings)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "example"}, &corev1.ConfigMap{})).Should(Succeed())
+		Expect(warnings.Messages()).Should(ConsistOf("example is deprecated"))
+	})
+})
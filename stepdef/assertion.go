@@ -8,6 +8,7 @@ import (
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 )
 
 var (
@@ -85,6 +86,20 @@ func RetryK8sError(err error) (bool, time.Duration) {
 	return retryable, 0
 }
 
+// RetryConnectionError retries errors connecting to the API server. Responses of the API
+// server, including internal errors, are not retried, e.g. to report the response of a
+// failing admission webhook.
+func RetryConnectionError(err error) (bool, time.Duration) {
+	if err == nil {
+		return false, 0
+	}
+	retry := utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err) ||
+		utilnet.IsHTTP2ConnectionLost(err)
+	return retry, time.Second
+}
+
 func isRuntime(err error) bool {
 	return runtime.IsMissingKind(err) ||
 		runtime.IsMissingVersion(err) ||
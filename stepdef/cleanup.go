@@ -0,0 +1,38 @@
+package stepdef
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CleanupPolicy controls how resources created during a scenario are removed
+// once the scenario has finished.
+type CleanupPolicy struct {
+	// Wait blocks cleanup until the deleted resource is gone from the API server.
+	Wait bool
+	// Timeout is how long to wait for a resource to be gone before reporting it
+	// as stuck terminating.
+	Timeout time.Duration
+	// PropagationPolicy is sent with the delete request. When waiting and unset,
+	// Foreground is used so that dependents are also gone when cleanup returns.
+	PropagationPolicy metav1.DeletionPropagation
+}
+
+var DefaultCleanupPolicy = CleanupPolicy{
+	Timeout: 2 * time.Minute,
+}
+
+type cleanupPolicyKey struct{}
+
+func WithCleanupPolicy(ctx context.Context, policy CleanupPolicy) context.Context {
+	return context.WithValue(ctx, cleanupPolicyKey{}, policy)
+}
+
+func CleanupPolicyFrom(ctx context.Context) CleanupPolicy {
+	if policy, ok := ctx.Value(cleanupPolicyKey{}).(CleanupPolicy); ok {
+		return policy
+	}
+	return DefaultCleanupPolicy
+}
@@ -0,0 +1,128 @@
+package stepdef
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Clients for a cluster. They are expensive to create, discovery and the RESTMapper
+// are cached within them, so they are created once per run and shared by every step.
+type Clients struct {
+	Config    *rest.Config
+	Scheme    *runtime.Scheme
+	Mapper    meta.RESTMapper
+	Client    client.WithWatch
+	Clientset *kubernetes.Clientset
+
+	httpClient   *http.Client
+	mu           sync.Mutex
+	impersonated map[string]*Clients
+}
+
+// NewClients creates clients which share a single http client and RESTMapper
+func NewClients(cfg *rest.Config) (*Clients, error) {
+	httpClient, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, err := apiutil.NewDynamicRESTMapper(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.NewWithWatch(cfg, client.Options{
+		HTTPClient: httpClient,
+		Scheme:     Scheme,
+		Mapper:     mapper,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfigAndClient(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Clients{
+		Config:    cfg,
+		Scheme:    Scheme,
+		Mapper:    mapper,
+		Client:    c,
+		Clientset: cs,
+
+		httpClient: httpClient,
+	}, nil
+}
+
+type clientsKey struct{}
+
+// WithClients returns a context whose steps use the clients
+func WithClients(ctx context.Context, clients *Clients) context.Context {
+	return context.WithValue(ctx, clientsKey{}, clients)
+}
+
+// ClientsFunc returns clients, see LazyClients
+type ClientsFunc func() (*Clients, error)
+
+// LazyClients returns a ClientsFunc which creates clients from the config of newConfig the
+// first time they are needed, later calls return the same clients or error. Runs whose
+// steps never talk to a cluster do not need a kubeconfig.
+func LazyClients(newConfig func() (*rest.Config, error)) ClientsFunc {
+	return sync.OnceValues(func() (*Clients, error) {
+		cfg, err := newConfig()
+		if err != nil {
+			return nil, err
+		}
+		return NewClients(cfg)
+	})
+}
+
+type lazyClientsKey struct{}
+
+// WithLazyClients returns a context whose steps use the clients of f
+func WithLazyClients(ctx context.Context, f ClientsFunc) context.Context {
+	return context.WithValue(ctx, lazyClientsKey{}, f)
+}
+
+var defaultClients struct {
+	sync.Mutex
+	*Clients
+}
+
+// ClientsFrom returns the clients of the DefaultCluster from the context. Contexts
+// without clients share clients created from RestConfigFrom the first time they are needed.
+func ClientsFrom(ctx context.Context) (*Clients, error) {
+	if clients, ok := ctx.Value(clientsKey{}).(*Clients); ok && clients != nil {
+		return clients, nil
+	}
+	if f, ok := ctx.Value(lazyClientsKey{}).(ClientsFunc); ok && f != nil {
+		return f()
+	}
+
+	defaultClients.Lock()
+	defer defaultClients.Unlock()
+	if defaultClients.Clients != nil {
+		return defaultClients.Clients, nil
+	}
+
+	cfg, err := RestConfigFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := NewClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defaultClients.Clients = clients
+	return clients, nil
+}
@@ -0,0 +1,140 @@
+package stepdef_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// apiServer serves just enough of the API for discovery and getting a ConfigMap
+func apiServer() *httptest.Server {
+	mux := http.NewServeMux()
+	write := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		write(w, metav1.APIVersions{Versions: []string{"v1"}})
+	})
+	mux.HandleFunc("/apis", func(w http.ResponseWriter, r *http.Request) {
+		write(w, metav1.APIGroupList{})
+	})
+	mux.HandleFunc("/api/v1", func(w http.ResponseWriter, r *http.Request) {
+		write(w, metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: metav1.Verbs{"get"}}},
+		})
+	})
+	mux.HandleFunc("/api/v1/namespaces/default/configmaps/example", func(w http.ResponseWriter, r *http.Request) {
+		write(w, corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+var _ = Describe("Clients", func() {
+	It("should give every T the clients of the context", func() {
+		server := apiServer()
+		defer server.Close()
+
+		clients, err := stepdef.NewClients(&rest.Config{Host: server.URL})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ctx := stepdef.WithClients(store.NewStoreFor(context.Background()), clients)
+		Expect(stepdef.ClientsFrom(ctx)).Should(BeIdenticalTo(clients))
+
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(t.Client).Should(BeIdenticalTo(clients.Client))
+		Expect(t.Config).Should(BeIdenticalTo(clients.Config))
+
+		cm := &corev1.ConfigMap{}
+		Expect(t.Client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "example"}, cm)).Should(Succeed())
+		Expect(cm.Name).Should(Equal("example"))
+	})
+
+	It("should create lazy clients once when a step first needs them", func() {
+		server := apiServer()
+		defer server.Close()
+
+		loaded := 0
+		ctx := stepdef.WithLazyClients(store.NewStoreFor(context.Background()), stepdef.LazyClients(func() (*rest.Config, error) {
+			loaded++
+			return &rest.Config{Host: server.URL}, nil
+		}))
+		Expect(loaded).Should(BeZero())
+
+		clients, err := stepdef.ClientsFrom(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(stepdef.ClientsFrom(ctx)).Should(BeIdenticalTo(clients))
+		Expect(stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil).Client).Should(BeIdenticalTo(clients.Client))
+		Expect(loaded).Should(Equal(1))
+	})
+
+	It("should only fail without a kubeconfig when a step needs clients", func() {
+		ctx := stepdef.WithLazyClients(store.NewStoreFor(context.Background()), stepdef.LazyClients(func() (*rest.Config, error) {
+			return nil, errors.New("could not load kubeconfig")
+		}))
+
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(t.Client).Should(BeNil())
+		_, err := stepdef.ClientsFrom(ctx)
+		Expect(err).Should(MatchError("could not load kubeconfig"))
+	})
+})
+
+// BenchmarkStepClients compares sharing clients between steps with creating them
+// for every step, which repeats discovery each time
+func BenchmarkStepClients(b *testing.B) {
+	log.SetLogger(logr.Discard())
+	server := apiServer()
+	defer server.Close()
+	// without a rate limit so that only the cost of the clients is measured
+	cfg := &rest.Config{Host: server.URL, QPS: -1}
+	key := client.ObjectKey{Namespace: "default", Name: "example"}
+
+	step := func(b *testing.B, ctx context.Context) {
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "bench"}, nil)
+		err := t.Client.Get(ctx, key, &corev1.ConfigMap{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		clients, err := stepdef.NewClients(cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ctx := stepdef.WithClients(store.NewStoreFor(context.Background()), clients)
+		for i := 0; i < b.N; i++ {
+			step(b, ctx)
+		}
+	})
+
+	b.Run("per-step", func(b *testing.B) {
+		ctx := store.NewStoreFor(context.Background())
+		for i := 0; i < b.N; i++ {
+			clients, err := stepdef.NewClients(cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			step(b, stepdef.WithClients(ctx, clients))
+		}
+	})
+}
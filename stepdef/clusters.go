@@ -0,0 +1,84 @@
+package stepdef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testernetes/bdk/store"
+)
+
+// DefaultCluster is the cluster of the --kubeconfig and --context flags
+const DefaultCluster = "default"
+
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+type clustersKey struct{}
+type clusterKey struct{}
+
+// WithClusters returns a context with named clusters which steps can switch to in
+// addition to the DefaultCluster
+func WithClusters(ctx context.Context, clusters map[string]*Clients) context.Context {
+	lazy := make(map[string]ClientsFunc, len(clusters))
+	for name, clients := range clusters {
+		clients := clients
+		lazy[name] = func() (*Clients, error) { return clients, nil }
+	}
+	return WithLazyClusters(ctx, lazy)
+}
+
+// WithLazyClusters is WithClusters for clients which are created when first needed,
+// see LazyClients
+func WithLazyClusters(ctx context.Context, clusters map[string]ClientsFunc) context.Context {
+	return context.WithValue(ctx, clustersKey{}, clusters)
+}
+
+// ClientsFor returns the clients of the named cluster
+func ClientsFor(ctx context.Context, cluster string) (*Clients, error) {
+	if cluster == "" || cluster == DefaultCluster {
+		return ClientsFrom(ctx)
+	}
+	clusters, _ := ctx.Value(clustersKey{}).(map[string]ClientsFunc)
+	clients, ok := clusters[cluster]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, clusters are configured with --cluster", ErrUnknownCluster, cluster)
+	}
+	return clients()
+}
+
+// WithCluster returns a context whose steps use the named cluster
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	return context.WithValue(ctx, clusterKey{}, cluster)
+}
+
+// ClusterFrom returns the cluster a step should use, the cluster of the context
+// if there is one, otherwise the active cluster of the scenario
+func ClusterFrom(ctx context.Context) string {
+	if cluster, ok := ctx.Value(clusterKey{}).(string); ok && cluster != "" {
+		return cluster
+	}
+	if cluster, err := store.Load[string](ctx, "active-cluster"); err == nil && cluster != "" {
+		return cluster
+	}
+	return DefaultCluster
+}
+
+// SetActiveCluster switches the cluster of later steps in the scenario
+func SetActiveCluster(ctx context.Context, cluster string) error {
+	if _, err := ClientsFor(ctx, cluster); err != nil {
+		return err
+	}
+	store.Save(ctx, "active-cluster", cluster)
+	return nil
+}
+
+// SetClusterOf remembers which cluster a reference belongs to
+func SetClusterOf(ctx context.Context, ref, cluster string) {
+	store.Save(ctx, "cluster-of-"+ref, cluster)
+}
+
+// ClusterOf returns the cluster a reference belongs to, if it is known
+func ClusterOf(ctx context.Context, ref string) (string, bool) {
+	cluster, err := store.Load[string](ctx, "cluster-of-"+ref)
+	return cluster, err == nil
+}
@@ -0,0 +1,57 @@
+package stepdef_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Clusters", func() {
+	var (
+		ctx       context.Context
+		def, east *stepdef.Clients
+	)
+
+	BeforeEach(func() {
+		var err error
+		def, err = stepdef.NewClients(&rest.Config{Host: "https://default.example.com"})
+		Expect(err).ShouldNot(HaveOccurred())
+		east, err = stepdef.NewClients(&rest.Config{Host: "https://east.example.com"})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ctx = stepdef.WithClients(store.NewStoreFor(context.Background()), def)
+		ctx = stepdef.WithClusters(ctx, map[string]*stepdef.Clients{"east": east})
+	})
+
+	It("should return the clients of a cluster", func() {
+		Expect(stepdef.ClientsFor(ctx, stepdef.DefaultCluster)).Should(BeIdenticalTo(def))
+		Expect(stepdef.ClientsFor(ctx, "east")).Should(BeIdenticalTo(east))
+		_, err := stepdef.ClientsFor(ctx, "west")
+		Expect(err).Should(MatchError(stepdef.ErrUnknownCluster))
+	})
+
+	It("should switch the active cluster", func() {
+		Expect(stepdef.ClusterFrom(ctx)).Should(Equal(stepdef.DefaultCluster))
+		Expect(stepdef.SetActiveCluster(ctx, "west")).Should(MatchError(stepdef.ErrUnknownCluster))
+
+		Expect(stepdef.SetActiveCluster(ctx, "east")).Should(Succeed())
+		Expect(stepdef.ClusterFrom(ctx)).Should(Equal("east"))
+		Expect(stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil).Config).Should(BeIdenticalTo(east.Config))
+
+		Expect(stepdef.ClusterFrom(stepdef.WithCluster(ctx, stepdef.DefaultCluster))).Should(Equal(stepdef.DefaultCluster))
+	})
+
+	It("should remember the cluster of a reference", func() {
+		_, known := stepdef.ClusterOf(ctx, "cm")
+		Expect(known).Should(BeFalse())
+
+		stepdef.SetClusterOf(ctx, "cm", "east")
+		cluster, known := stepdef.ClusterOf(ctx, "cm")
+		Expect(known).Should(BeTrue())
+		Expect(cluster).Should(Equal("east"))
+	})
+})
@@ -0,0 +1,164 @@
+package stepdef
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/onsi/gomega/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Condition is the condition a resource is expected to have, e.g. Ready=True with reason
+// Provisioned. An empty Reason or Message matches any.
+type Condition struct {
+	Type    string
+	Status  metav1.ConditionStatus
+	Reason  string
+	Message string
+}
+
+func (c Condition) String() string {
+	s := c.Type + "=" + string(c.Status)
+	if c.Reason != "" {
+		s += " with reason " + c.Reason
+	}
+	if c.Message != "" {
+		s += fmt.Sprintf(" with message '%s'", c.Message)
+	}
+	return s
+}
+
+var conditionRe = regexp.MustCompile(`^([A-Za-z][-A-Za-z0-9_.]*)(?:=(True|False|Unknown))?(?: with reason ([A-Za-z][A-Za-z0-9_,:]*))?(?: with message '([^']*)')?$`)
+
+// parseCondition parses Type[=Status] [with reason Reason] [with message 'text'], the status
+// defaults to True
+func parseCondition(ctx context.Context, s string) (reflect.Value, error) {
+	m := conditionRe.FindStringSubmatch(s)
+	if m == nil {
+		return reflect.Value{}, fmt.Errorf(CannotParse, s, "condition, expected Type=Status [with reason Reason] [with message 'text']")
+	}
+	c := Condition{Type: m[1], Status: metav1.ConditionTrue, Reason: m[3], Message: m[4]}
+	if m[2] != "" {
+		c.Status = metav1.ConditionStatus(m[2])
+	}
+	return reflect.ValueOf(c), nil
+}
+
+// Conditions returns the status.conditions and metadata.generation of an object
+func Conditions(obj runtime.Object) ([]metav1.Condition, int64, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, 0, err
+		}
+		u = &unstructured.Unstructured{Object: content}
+	}
+
+	items, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions := make([]metav1.Condition, 0, len(items))
+	for _, item := range items {
+		content, ok := item.(map[string]any)
+		if !ok {
+			return nil, 0, fmt.Errorf("status.conditions of %s is not a list of objects", u.GetName())
+		}
+		c := metav1.Condition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &c); err != nil {
+			return nil, 0, fmt.Errorf("status.conditions of %s: %w", u.GetName(), err)
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions, u.GetGeneration(), nil
+}
+
+// HaveCondition matches objects which have the condition in status.conditions. A condition
+// whose observedGeneration is older than metadata.generation is stale and does not match.
+func HaveCondition(expected Condition) types.GomegaMatcher {
+	return &haveConditionMatcher{expected: expected}
+}
+
+type haveConditionMatcher struct {
+	expected   Condition
+	name       string
+	conditions []metav1.Condition
+	generation int64
+}
+
+func (m *haveConditionMatcher) Match(actual any) (bool, error) {
+	obj, ok := actual.(runtime.Object)
+	if !ok {
+		return false, fmt.Errorf("HaveCondition expects a Kubernetes object, got %T", actual)
+	}
+	if o, ok := actual.(metav1.Object); ok {
+		m.name = fmt.Sprintf("%s %s", obj.GetObjectKind().GroupVersionKind().Kind, o.GetName())
+	}
+
+	var err error
+	m.conditions, m.generation, err = Conditions(obj)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range m.conditions {
+		if c.Type == m.expected.Type {
+			return m.matches(c), nil
+		}
+	}
+	return false, nil
+}
+
+func (m *haveConditionMatcher) matches(c metav1.Condition) bool {
+	return c.Status == m.expected.Status &&
+		(m.expected.Reason == "" || c.Reason == m.expected.Reason) &&
+		(m.expected.Message == "" || strings.Contains(c.Message, m.expected.Message)) &&
+		!stale(c, m.generation)
+}
+
+// stale is true for a condition set for an older generation of the object
+func stale(c metav1.Condition, generation int64) bool {
+	return c.ObservedGeneration != 0 && generation != 0 && c.ObservedGeneration < generation
+}
+
+func (m *haveConditionMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("expected %s to have condition %s\n%s", m.name, m.expected, m.table())
+}
+
+func (m *haveConditionMatcher) NegatedFailureMessage(actual any) string {
+	return fmt.Sprintf("expected %s not to have condition %s\n%s", m.name, m.expected, m.table())
+}
+
+// table renders the current conditions like kubectl
+func (m *haveConditionMatcher) table() string {
+	if len(m.conditions) == 0 {
+		return "it has no conditions"
+	}
+
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tSTATUS\tREASON\tGENERATION\tMESSAGE")
+	for _, c := range m.conditions {
+		generation := fmt.Sprintf("%d/%d", c.ObservedGeneration, m.generation)
+		if c.ObservedGeneration == 0 {
+			generation = "-"
+		} else if stale(c, m.generation) {
+			generation += " (stale)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, generation, c.Message)
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
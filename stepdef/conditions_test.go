@@ -0,0 +1,93 @@
+package stepdef_test
+
+import (
+	"context"
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Conditions", func() {
+	parse := func(s string) stepdef.Condition {
+		v, err := stepdef.StringParsers.Parse(context.Background(), s, reflect.TypeOf(stepdef.Condition{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		return v.Interface().(stepdef.Condition)
+	}
+
+	DescribeTable("parsing a condition",
+		func(s string, expected stepdef.Condition) {
+			Expect(parse(s)).Should(Equal(expected))
+			Expect(expected.String()).Should(HavePrefix(expected.Type + "="))
+		},
+		Entry("type only", "Ready", stepdef.Condition{Type: "Ready", Status: metav1.ConditionTrue}),
+		Entry("status", "Degraded=False", stepdef.Condition{Type: "Degraded", Status: metav1.ConditionFalse}),
+		Entry("reason", "Ready=True with reason Provisioned", stepdef.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Provisioned"}),
+		Entry("message", "Ready=Unknown with reason Pending with message 'waiting for db'",
+			stepdef.Condition{Type: "Ready", Status: metav1.ConditionUnknown, Reason: "Pending", Message: "waiting for db"}),
+	)
+
+	It("should not parse other statuses", func() {
+		_, err := stepdef.StringParsers.Parse(context.Background(), "Ready=Yes", reflect.TypeOf(stepdef.Condition{}))
+		Expect(err).Should(HaveOccurred())
+	})
+
+	Context("matching", func() {
+		var db *unstructured.Unstructured
+
+		BeforeEach(func() {
+			db = &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"metadata":   map[string]any{"name": "db", "generation": int64(3)},
+				"status": map[string]any{
+					"conditions": []any{
+						map[string]any{"type": "Ready", "status": "True", "reason": "Provisioned", "message": "all replicas are ready", "observedGeneration": int64(3), "lastTransitionTime": "2024-01-01T00:00:00Z"},
+						map[string]any{"type": "Synced", "status": "True", "reason": "Applied", "observedGeneration": int64(2), "lastTransitionTime": "2024-01-01T00:00:00Z"},
+					},
+				},
+			}}
+		})
+
+		It("should match the type, status, reason and message", func() {
+			Expect(db).Should(stepdef.HaveCondition(parse("Ready")))
+			Expect(db).Should(stepdef.HaveCondition(parse("Ready=True with reason Provisioned with message 'replicas'")))
+			Expect(db).ShouldNot(stepdef.HaveCondition(parse("Ready=False")))
+			Expect(db).ShouldNot(stepdef.HaveCondition(parse("Ready with reason Failed")))
+			Expect(db).ShouldNot(stepdef.HaveCondition(parse("Ready with message 'degraded'")))
+			Expect(db).ShouldNot(stepdef.HaveCondition(parse("Missing")))
+		})
+
+		It("should not match stale conditions", func() {
+			Expect(db).ShouldNot(stepdef.HaveCondition(parse("Synced")))
+		})
+
+		It("should print the current conditions", func() {
+			matcher := stepdef.HaveCondition(parse("Synced=True with reason Applied"))
+			Expect(matcher.Match(db)).Should(BeFalse())
+			Expect(matcher.FailureMessage(db)).Should(Equal(`expected Database db to have condition Synced=True with reason Applied
+TYPE    STATUS  REASON       GENERATION   MESSAGE
+Ready   True    Provisioned  3/3          all replicas are ready
+Synced  True    Applied      2/3 (stale)`))
+		})
+
+		It("should match typed objects", func() {
+			deploy := &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: "app"},
+				Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, Status: "True", Reason: "MinimumReplicasAvailable"},
+				}},
+			}
+			Expect(deploy).Should(stepdef.HaveCondition(parse("Available with reason MinimumReplicasAvailable")))
+
+			matcher := stepdef.HaveCondition(parse("Progressing"))
+			Expect(matcher.Match(&appsv1.Deployment{})).Should(BeFalse())
+			Expect(matcher.FailureMessage(nil)).Should(HaveSuffix("it has no conditions"))
+		})
+	})
+})
@@ -3,9 +3,11 @@ package stepdef
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 
 	messages "github.com/cucumber/messages/go/v21"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
 )
 
@@ -25,24 +27,58 @@ func unmarshalToClientObject(b []byte, targetType reflect.Type) (_ reflect.Value
 		return reflect.Value{}, err
 	}
 
-	err = yaml.Unmarshal(b, o)
+	// typed objects are validated against their type, unknown fields are an error
+	if _, ok := o.(*unstructured.Unstructured); ok {
+		err = yaml.Unmarshal(b, o)
+	} else {
+		err = yaml.UnmarshalStrict(b, o)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	err = checkKind(o, o.GetObjectKind().GroupVersionKind())
 	if err != nil {
 		return reflect.Value{}, err
 	}
 
 	apiVersion, kind := o.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
 	if apiVersion == "" {
-		err = errors.Join(errors.New("Provided test case resource has an empty API Version"))
+		err = errors.Join(err, errors.New("Provided test case resource has an empty API Version"))
 	}
 	if kind == "" {
-		err = errors.Join(errors.New("Provided test case resource has an empty Kind"))
+		err = errors.Join(err, errors.New("Provided test case resource has an empty Kind"))
 	}
 	if o.GetName() == "" {
-		err = errors.Join(errors.New("Provided test case resource has an empty Name"))
+		err = errors.Join(err, errors.New("Provided test case resource has an empty Name"))
 	}
 	return reflect.ValueOf(o), err
 }
 
 func ParseDocStringToClientObject(ctx context.Context, ds *messages.DocString, targetType reflect.Type) (_ reflect.Value, err error) {
+	if targetType == resourceSetType {
+		set, err := ParseResourceSet([]byte(ds.Content))
+		return reflect.ValueOf(set), err
+	}
+	if targetType == resourcesType {
+		r, err := ParseResources([]byte(ds.Content))
+		return reflect.ValueOf(r), err
+	}
 	return unmarshalToClientObject([]byte(ds.Content), targetType)
 }
+
+// ParseDocStringToReplacement parses a manifest into an unstructured object without requiring
+// an apiVersion, kind or name
+func ParseDocStringToReplacement(ctx context.Context, ds *messages.DocString, targetType reflect.Type) (reflect.Value, error) {
+	if targetType != reflect.TypeOf(&unstructured.Unstructured{}) {
+		return reflect.Value{}, fmt.Errorf("cannot parse a replacement into a %s", targetType)
+	}
+	o := &unstructured.Unstructured{Object: map[string]any{}}
+	if err := yaml.Unmarshal([]byte(ds.Content), &o.Object); err != nil {
+		return reflect.Value{}, err
+	}
+	if o.Object == nil {
+		o.Object = map[string]any{}
+	}
+	return reflect.ValueOf(o), nil
+}
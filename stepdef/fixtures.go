@@ -0,0 +1,117 @@
+package stepdef
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	fileScheme  = "file://"
+	embedScheme = "embed:"
+)
+
+var embedded struct {
+	sync.Mutex
+	fsys []fs.FS
+}
+
+// RegisterFixtures adds a file system, such as an embed.FS of a plugin, whose files are
+// referred to with the embed: scheme, e.g. embed:manifests/cm.yaml
+func RegisterFixtures(fsys fs.FS) {
+	embedded.Lock()
+	defer embedded.Unlock()
+	embedded.fsys = append(embedded.fsys, fsys)
+}
+
+type featurePathKey struct{}
+
+// WithFeaturePath returns a context whose relative filenames are resolved against the
+// directory of the feature file
+func WithFeaturePath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, featurePathKey{}, path)
+}
+
+type fixturesDirsKey struct{}
+
+// WithFixturesDirs returns a context whose relative filenames are also searched for in the
+// directories, after the directory of the feature file
+func WithFixturesDirs(ctx context.Context, dirs []string) context.Context {
+	return context.WithValue(ctx, fixturesDirsKey{}, dirs)
+}
+
+// ResolveFixture returns the file system and path of a filename given in a step. Filenames
+// with the embed: scheme are looked up in the fixtures of plugins. Relative filenames, also
+// with the file:// scheme, are searched for in the directory of the feature file, the
+// fixtures directories and lastly the working directory. A filename may be a glob.
+func ResolveFixture(ctx context.Context, filename string) (fs.FS, string, error) {
+	if name, ok := strings.CutPrefix(filename, embedScheme); ok {
+		name = path.Clean(strings.TrimPrefix(name, "/"))
+		embedded.Lock()
+		defer embedded.Unlock()
+		for _, fsys := range embedded.fsys {
+			if fixtureExists(fsys, name) {
+				return fsys, name, nil
+			}
+		}
+		return nil, "", fmt.Errorf("%s is not a fixture of any plugin", filename)
+	}
+
+	name, err := ResolvePath(ctx, filename)
+	if err != nil {
+		return nil, "", err
+	}
+	return osFixture(name)
+}
+
+// ResolvePath returns the path of a file or directory given in a step like ResolveFixture,
+// for tools which read files themselves. Embedded fixtures have no path.
+func ResolvePath(ctx context.Context, filename string) (string, error) {
+	if strings.HasPrefix(filename, embedScheme) {
+		return "", fmt.Errorf("%s is embedded in a plugin, a path on disk is required", filename)
+	}
+	name := strings.TrimPrefix(filename, fileScheme)
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+
+	var dirs []string
+	if feature, ok := ctx.Value(featurePathKey{}).(string); ok {
+		dirs = append(dirs, filepath.Dir(feature))
+	}
+	if fixtures, ok := ctx.Value(fixturesDirsKey{}).([]string); ok {
+		dirs = append(dirs, fixtures...)
+	}
+	dirs = append(dirs, ".")
+
+	for _, dir := range dirs {
+		fsys, p, err := osFixture(filepath.Join(dir, name))
+		if err == nil && fixtureExists(fsys, p) {
+			return filepath.Abs(filepath.Join(dir, name))
+		}
+	}
+	return "", fmt.Errorf("%s was not found in %s: %w", filename, strings.Join(dirs, ", "), fs.ErrNotExist)
+}
+
+// osFixture returns the root of the file system and the path of name within it
+func osFixture(name string) (fs.FS, string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, "", err
+	}
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+	return os.DirFS(root), filepath.ToSlash(strings.TrimPrefix(abs, root)), nil
+}
+
+func fixtureExists(fsys fs.FS, name string) bool {
+	if _, err := fs.Stat(fsys, name); err == nil {
+		return true
+	}
+	matches, err := fs.Glob(fsys, name)
+	return err == nil && len(matches) > 0
+}
@@ -0,0 +1,82 @@
+package stepdef_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Fixtures", func() {
+	var features, fixtures string
+	var ctx context.Context
+
+	read := func(filename string) string {
+		fsys, name, err := stepdef.ResolveFixture(ctx, filename)
+		Expect(err).ShouldNot(HaveOccurred())
+		b, err := fs.ReadFile(fsys, name)
+		Expect(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	BeforeEach(func() {
+		features = GinkgoT().TempDir()
+		fixtures = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(features, "cm.yaml"), []byte("feature"), 0o644)).Should(Succeed())
+		Expect(os.WriteFile(filepath.Join(fixtures, "cm.yaml"), []byte("fixtures"), 0o644)).Should(Succeed())
+		Expect(os.WriteFile(filepath.Join(fixtures, "other.yaml"), []byte("other"), 0o644)).Should(Succeed())
+
+		ctx = stepdef.WithFeaturePath(context.Background(), filepath.Join(features, "example.feature"))
+		ctx = stepdef.WithFixturesDirs(ctx, []string{fixtures})
+	})
+
+	It("should resolve relative to the feature file first", func() {
+		Expect(read("cm.yaml")).Should(Equal("feature"))
+		Expect(read("file://cm.yaml")).Should(Equal("feature"))
+	})
+
+	It("should search the fixtures directories", func() {
+		Expect(read("other.yaml")).Should(Equal("other"))
+
+		// a glob matching next to the feature file is not searched for elsewhere
+		fsys, name, err := stepdef.ResolveFixture(ctx, "*.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(fs.Glob(fsys, name)).Should(ConsistOf(HaveSuffix("cm.yaml")))
+	})
+
+	It("should accept absolute paths", func() {
+		Expect(read(filepath.Join(fixtures, "cm.yaml"))).Should(Equal("fixtures"))
+		Expect(read("file://" + filepath.Join(fixtures, "cm.yaml"))).Should(Equal("fixtures"))
+	})
+
+	It("should list where it searched", func() {
+		_, _, err := stepdef.ResolveFixture(ctx, "missing.yaml")
+		Expect(err).Should(MatchError(fs.ErrNotExist))
+		Expect(err).Should(MatchError(ContainSubstring(fixtures)))
+	})
+
+	It("should resolve embedded fixtures", func() {
+		stepdef.RegisterFixtures(fstest.MapFS{
+			"manifests/cm.yaml": &fstest.MapFile{Data: []byte(configMap)},
+		})
+		Expect(read("embed:manifests/cm.yaml")).Should(Equal(configMap))
+
+		v, err := stepdef.ParseFileToClientObject(ctx, "embed:manifests/*", reflect.TypeOf(stepdef.ResourceSet{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface()).Should(HaveLen(1))
+
+		v, err = stepdef.ParseFileToClientObject(ctx, "embed:manifests/cm.yaml", reflect.TypeOf(&unstructured.Unstructured{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface().(*unstructured.Unstructured).GetName()).Should(Equal("example"))
+
+		_, _, err = stepdef.ResolveFixture(ctx, "embed:missing.yaml")
+		Expect(err).Should(MatchError("embed:missing.yaml is not a fixture of any plugin"))
+	})
+})
@@ -0,0 +1,26 @@
+package stepdef
+
+import "context"
+
+type HookType string
+
+const (
+	BeforeSuite    HookType = "BeforeSuite"
+	AfterSuite     HookType = "AfterSuite"
+	BeforeFeature  HookType = "BeforeFeature"
+	BeforeScenario HookType = "BeforeScenario"
+	AfterScenario  HookType = "AfterScenario"
+	AfterStep      HookType = "AfterStep"
+)
+
+var HookTypes = []HookType{BeforeSuite, AfterSuite, BeforeFeature, BeforeScenario, AfterScenario, AfterStep}
+
+// Hook is a function which runs around the suite, features, scenarios or steps.
+// Tags is a tag expression in the same format as the --tags flag of bdk test and
+// limits the hook to features or scenarios with matching tags. Suite hooks ignore Tags.
+type Hook struct {
+	Name     string
+	Type     HookType
+	Tags     string
+	Function func(context.Context, *T) error
+}
@@ -0,0 +1,117 @@
+package stepdef
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/testernetes/bdk/store"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Identity is a user which steps impersonate instead of using the identity of the kubeconfig
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// ServiceAccount returns the identity of a service account
+func ServiceAccount(namespace, name string) Identity {
+	return Identity{
+		User:   fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+		Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace},
+	}
+}
+
+func (id Identity) String() string {
+	if len(id.Groups) == 0 {
+		return id.User
+	}
+	if id.User == "" {
+		return "groups " + strings.Join(id.Groups, ",")
+	}
+	return fmt.Sprintf("%s in groups %s", id.User, strings.Join(id.Groups, ","))
+}
+
+func (id Identity) key() string {
+	return id.User + "\x00" + strings.Join(id.Groups, "\x00")
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context whose steps impersonate the identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the identity a step should impersonate, the identity of the
+// context if there is one, otherwise the active identity of the scenario. It returns
+// false if the step should use the identity of the kubeconfig.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	if id, ok := ctx.Value(identityKey{}).(Identity); ok && id.User != "" {
+		return id, true
+	}
+	if id, err := store.Load[Identity](ctx, "active-identity"); err == nil && id.User != "" {
+		return id, true
+	}
+	return Identity{}, false
+}
+
+// SetActiveIdentity makes later steps in the scenario impersonate the identity. An
+// empty identity switches back to the identity of the kubeconfig.
+func SetActiveIdentity(ctx context.Context, id Identity) {
+	store.Save(ctx, "active-identity", id)
+}
+
+// Impersonate returns clients which impersonate the identity. They share the
+// RESTMapper, so discovery is done with the identity of the kubeconfig, and are
+// created once per identity.
+func (c *Clients) Impersonate(id Identity) (*Clients, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if clients, ok := c.impersonated[id.key()]; ok {
+		return clients, nil
+	}
+
+	cfg := rest.CopyConfig(c.Config)
+	cfg.Impersonate = rest.ImpersonationConfig{
+		UserName: id.User,
+		Groups:   id.Groups,
+	}
+
+	httpClient, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := client.NewWithWatch(cfg, client.Options{
+		HTTPClient: httpClient,
+		Scheme:     c.Scheme,
+		Mapper:     c.Mapper,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfigAndClient(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := &Clients{
+		Config:    cfg,
+		Scheme:    c.Scheme,
+		Mapper:    c.Mapper,
+		Client:    cl,
+		Clientset: cs,
+
+		httpClient: httpClient,
+	}
+	if c.impersonated == nil {
+		c.impersonated = map[string]*Clients{}
+	}
+	c.impersonated[id.key()] = clients
+	return clients, nil
+}
@@ -0,0 +1,90 @@
+package stepdef_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Identity", func() {
+	var (
+		ctx     context.Context
+		clients *stepdef.Clients
+		server  *httptest.Server
+		mu      sync.Mutex
+		users   []string
+	)
+
+	BeforeEach(func() {
+		api := apiServer()
+		DeferCleanup(api.Close)
+		users = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			users = append(users, r.Header.Get("Impersonate-User"))
+			mu.Unlock()
+			api.Config.Handler.ServeHTTP(w, r)
+		}))
+		DeferCleanup(server.Close)
+
+		var err error
+		clients, err = stepdef.NewClients(&rest.Config{Host: server.URL})
+		Expect(err).ShouldNot(HaveOccurred())
+		ctx = stepdef.WithClients(store.NewStoreFor(context.Background()), clients)
+	})
+
+	get := func(t *stepdef.T) string {
+		mu.Lock()
+		users = nil
+		mu.Unlock()
+		Expect(t.Client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "example"}, &corev1.ConfigMap{})).Should(Succeed())
+		mu.Lock()
+		defer mu.Unlock()
+		return users[len(users)-1]
+	}
+
+	It("should use the identity of the kubeconfig by default", func() {
+		_, ok := stepdef.IdentityFrom(ctx)
+		Expect(ok).Should(BeFalse())
+
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(t.Identity).Should(BeNil())
+		Expect(get(t)).Should(BeEmpty())
+	})
+
+	It("should impersonate the active identity of the scenario", func() {
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{User: "alice", Groups: []string{"dev", "qa"}})
+
+		t := stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(t.Identity.String()).Should(Equal("alice in groups dev,qa"))
+		Expect(t.Config.Impersonate.Groups).Should(Equal([]string{"dev", "qa"}))
+		Expect(get(t)).Should(Equal("alice"))
+
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{})
+		t = stepdef.NewT(ctx, stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(get(t)).Should(BeEmpty())
+	})
+
+	It("should prefer the identity of the context", func() {
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{User: "alice"})
+		t := stepdef.NewT(stepdef.WithIdentity(ctx, stepdef.ServiceAccount("default", "builder")), stepdef.StepDefinition{Name: "test"}, nil)
+		Expect(get(t)).Should(Equal("system:serviceaccount:default:builder"))
+	})
+
+	It("should create impersonated clients once per identity", func() {
+		alice, err := clients.Impersonate(stepdef.Identity{User: "alice"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(clients.Impersonate(stepdef.Identity{User: "alice"})).Should(BeIdenticalTo(alice))
+		Expect(clients.Impersonate(stepdef.Identity{User: "bob"})).ShouldNot(BeIdenticalTo(alice))
+		Expect(alice.Mapper).Should(BeIdenticalTo(clients.Mapper))
+	})
+})
@@ -0,0 +1,55 @@
+package stepdef
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultFieldManager is the field manager of requests which do not name one
+const DefaultFieldManager = "bdk"
+
+var fieldPathRe = regexp.MustCompile(`^{((?:\.[^.{}\[\]]+)+)}$`)
+
+// FieldPath parses a jsonpath of field names, e.g. {.data.foo}, into the names of the fields
+func FieldPath(jsonpath string) ([]string, error) {
+	m := fieldPathRe.FindStringSubmatch(jsonpath)
+	if m == nil {
+		return nil, fmt.Errorf(CannotParse, jsonpath, "field path, expected field names such as {.data.foo}")
+	}
+	return strings.Split(strings.TrimPrefix(m[1], "."), "."), nil
+}
+
+// FieldManagers returns the managers which own the field at path. If path is empty,
+// it returns every manager of the object.
+func FieldManagers(obj client.Object, path []string) ([]string, error) {
+	var managers []string
+	for _, entry := range obj.GetManagedFields() {
+		if entry.FieldsV1 == nil || contains(entry.Manager, managers) {
+			continue
+		}
+		fields := map[string]any{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, fmt.Errorf("managed fields of %s: %w", entry.Manager, err)
+		}
+		if ownsField(fields, path) {
+			managers = append(managers, entry.Manager)
+		}
+	}
+	return managers, nil
+}
+
+// ownsField walks the fields of a FieldsV1 set, in which a field name is prefixed with f:
+func ownsField(fields map[string]any, path []string) bool {
+	for _, name := range path {
+		next, ok := fields["f:"+name].(map[string]any)
+		if !ok {
+			return false
+		}
+		fields = next
+	}
+	return true
+}
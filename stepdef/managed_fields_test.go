@@ -0,0 +1,44 @@
+package stepdef_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Managed fields", func() {
+	var cm *unstructured.Unstructured
+
+	BeforeEach(func() {
+		cm = &unstructured.Unstructured{}
+		cm.SetManagedFields([]metav1.ManagedFieldsEntry{
+			{Manager: "alice", Operation: metav1.ManagedFieldsOperationApply, FieldsType: "FieldsV1",
+				FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:foo":{},"f:shared":{}}}`)}},
+			{Manager: "bob", Operation: metav1.ManagedFieldsOperationApply, FieldsType: "FieldsV1",
+				FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:shared":{}},"f:metadata":{"f:labels":{"f:app":{}}}}`)}},
+		})
+	})
+
+	DescribeTable("owners of a field",
+		func(jsonpath string, managers []string) {
+			path, err := stepdef.FieldPath(jsonpath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stepdef.FieldManagers(cm, path)).Should(Equal(managers))
+		},
+		Entry("a field with one manager", "{.data.foo}", []string{"alice"}),
+		Entry("a field with shared ownership", "{.data.shared}", []string{"alice", "bob"}),
+		Entry("a parent field", "{.metadata.labels}", []string{"bob"}),
+		Entry("a field without a manager", "{.data.bar}", nil),
+	)
+
+	It("should return every manager without a path", func() {
+		Expect(stepdef.FieldManagers(cm, nil)).Should(Equal([]string{"alice", "bob"}))
+	})
+
+	It("should only parse field names", func() {
+		_, err := stepdef.FieldPath("{.spec.containers[0].image}")
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,89 @@
+package stepdef
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	messages "github.com/cucumber/messages/go/v21"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Mutation sets fields of an object. Unlike a manifest it can be applied again to a
+// newer version of the object, e.g. after a conflict.
+type Mutation []FieldValue
+
+// FieldValue is the value of the field at Path, a nil Value removes the field
+type FieldValue struct {
+	Path  []string
+	Value any
+}
+
+// Apply sets the fields of the mutation on the object
+func (m Mutation) Apply(obj *unstructured.Unstructured) error {
+	for _, f := range m {
+		if f.Value == nil {
+			unstructured.RemoveNestedField(obj.Object, f.Path...)
+			continue
+		}
+		if err := unstructured.SetNestedField(obj.Object, f.Value, f.Path...); err != nil {
+			return fmt.Errorf("cannot set .%s: %w", strings.Join(f.Path, "."), err)
+		}
+	}
+	return nil
+}
+
+// ParseMutation parses a table of field paths, e.g. .data.foo or {.data.foo}, and yaml values
+// into a Mutation. Without a table the mutation is empty.
+func ParseMutation(ctx context.Context, dt *messages.DataTable, targetType reflect.Type) (reflect.Value, error) {
+	if targetType != reflect.TypeOf(Mutation{}) {
+		return reflect.Value{}, fmt.Errorf(CannotParse, "DataTable (step argument)", targetType.String())
+	}
+	mutation := Mutation{}
+	if dt == nil {
+		return reflect.ValueOf(mutation), nil
+	}
+
+	for _, row := range dt.Rows {
+		if len(row.Cells) != 2 {
+			return reflect.Value{}, fmt.Errorf("table must be a width of 2 containing fields and values")
+		}
+		field := strings.TrimSpace(row.Cells[0].Value)
+		if !strings.HasPrefix(field, "{") {
+			field = "{." + strings.TrimPrefix(field, ".") + "}"
+		}
+		path, err := FieldPath(field)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		var value any
+		if err := yaml.Unmarshal([]byte(row.Cells[1].Value), &value); err != nil {
+			return reflect.Value{}, err
+		}
+		mutation = append(mutation, FieldValue{Path: path, Value: jsonValue(value)})
+	}
+	return reflect.ValueOf(mutation), nil
+}
+
+// jsonValue converts whole numbers to int64 like the values of unstructured objects
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = jsonValue(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+	return v
+}
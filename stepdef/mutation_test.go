@@ -0,0 +1,51 @@
+package stepdef_test
+
+import (
+	"context"
+	"reflect"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Mutation", func() {
+	parse := func(rows ...[2]string) stepdef.Mutation {
+		table := &messages.DataTable{}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, &messages.TableRow{Cells: []*messages.TableCell{{Value: row[0]}, {Value: row[1]}}})
+		}
+		v, err := stepdef.ParseMutation(context.Background(), table, reflect.TypeOf(stepdef.Mutation{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		return v.Interface().(stepdef.Mutation)
+	}
+
+	It("should set and remove fields", func() {
+		mutation := parse(
+			[2]string{".data.foo", "nobar"},
+			[2]string{"{.spec.replicas}", "3"},
+			[2]string{"metadata.labels", "{app: hello}"},
+			[2]string{".metadata.annotations", "null"},
+		)
+
+		obj := &unstructured.Unstructured{Object: map[string]any{}}
+		obj.SetAnnotations(map[string]string{"a": "b"})
+		Expect(mutation.Apply(obj)).Should(Succeed())
+		Expect(obj.Object).Should(Equal(map[string]any{
+			"data":     map[string]any{"foo": "nobar"},
+			"spec":     map[string]any{"replicas": int64(3)},
+			"metadata": map[string]any{"labels": map[string]any{"app": "hello"}},
+		}))
+
+		// an object can be deep copied after a mutation
+		Expect(obj.DeepCopy()).Should(Equal(obj))
+	})
+
+	It("should be empty without a table", func() {
+		v, err := stepdef.ParseMutation(context.Background(), nil, reflect.TypeOf(stepdef.Mutation{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface()).Should(BeEmpty())
+	})
+})
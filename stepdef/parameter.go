@@ -105,7 +105,11 @@ func (p DocStringArgument) Parse(ctx context.Context, s *messages.Step, t reflec
 	if s.DataTable != nil {
 		return reflect.Value{}, fmt.Errorf("expected a DocString but found a DataTable")
 	}
-	return p.parser(ctx, s.DocString, t)
+	ds, err := RenderDocString(ctx, s.DocString)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("could not render DocString template: %w", err)
+	}
+	return p.parser(ctx, ds, t)
 }
 
 func (p DocStringArgument) Print() string {
@@ -15,6 +15,7 @@ import (
 const (
 	BashChars             = `([a-zA-Z_][a-zA-Z0-9_]*)`
 	RFC1123               = `([a-z0-9]+[-a-z0-9]*[a-z0-9])`
+	exprReference         = `([a-z0-9]+[-a-z0-9]*[a-z0-9](?:/[a-z0-9]+/[a-z0-9](?:[-.a-z0-9]*[a-z0-9])?)?)`
 	DoubleQuoted          = `"([^"\\]*(?:\\.[^"\\]*)*)"`
 	SingleQuoted          = `'([^'\\]*(?:\\.[^'\\]*)*)'`
 	OneOrMultipleWords    = `([\w+\s*]+)`
@@ -29,6 +30,15 @@ const (
 	exprURLPath           = `([-a-zA-Z0-9()!@:%_\+.~#?&\/\/=]*)`
 	exprURLScheme         = `(http|https)`
 	exprPort              = `(\d{1,5})`
+	exprScope             = `(global|suite|feature|rule|scenario)`
+	exprAction            = `(creating|getting|listing|updating|patching|deleting)`
+	UserName              = `([^\s,]+)`
+	GroupNames            = `([^\s,]+(?:,[^\s,]+)*)`
+	exprSubject           = `((?:user [^\s,]+(?: in groups [^\s,]+(?:,[^\s,]+)*)?|group [^\s,]+|serviceaccount [a-z0-9](?:[-a-z0-9]*[a-z0-9])?/[a-z0-9](?:[-.a-z0-9]*[a-z0-9])?))`
+	exprVerb              = `([a-z]+|\*)`
+	exprResource          = `([a-z0-9*][-a-z0-9.*]*(?:/[a-z*]+)?(?: named [^\s]+)?)`
+	exprCondition         = `([A-Za-z][-A-Za-z0-9_.]*(?:=(?:True|False|Unknown))?(?: with reason [A-Za-z][A-Za-z0-9_,:]*)?(?: with message '[^']*')?)`
+	ServiceAccountRef     = `([a-z0-9](?:[-a-z0-9]*[a-z0-9])?/[a-z0-9](?:[-.a-z0-9]*[a-z0-9])?)`
 )
 
 var CreateOptions = dataTableArgument{
@@ -93,6 +103,35 @@ var PatchOptions = dataTableArgument{
 	parser: ParseClientOptions,
 }
 
+var Permissions = dataTableArgument{
+	name:        "Permissions",
+	description: `A table of permissions which are expected to be allowed or denied.`,
+	help: `https://kubernetes.io/docs/reference/access-authn-authz/authorization/#checking-api-access
+
+		The first row names the columns. verb, resource and allowed are required, subject and
+		namespace are optional. A permission without a namespace is checked cluster-wide.
+
+		| subject                        | verb   | resource                 | namespace | allowed |
+		| user alice in groups dev       | get    | pods/log                 | default   | yes     |
+		| group qa                       | delete | configmaps named example | default   | no      |
+		| serviceaccount default/builder | list   | deployments.apps         |           | no      |`,
+	parser: ParsePermissions,
+}
+
+var Fields = dataTableArgument{
+	name:        "Fields",
+	description: `(optional) A table of fields to set and their values.`,
+	help: `The first column is a path of field names, the second is a yaml value. A null value
+		removes the field.
+
+		Fields:
+		| .data.foo             | bar    |
+		| .spec.replicas        | 3      |
+		| .metadata.labels      | {a: b} |
+		| .metadata.annotations | null   |`,
+	parser: ParseMutation,
+}
+
 var PodLogOptions = dataTableArgument{
 	name:        "Pod Log Options",
 	description: `(optional) A table of additional client pod log options.`,
@@ -180,10 +219,37 @@ var Manifest = DocStringArgument{
 	description: `A Kubernetes manifest.`,
 	help: `https://kubernetes.io/docs/concepts/overview/working-with-objects/kubernetes-objects/
 
-		Can be yaml or json depending on the content type.`,
+		Can be yaml or json depending on the content type. Steps saving a resource accept
+		multiple --- separated documents.
+
+		A content type ending in +tmpl is rendered as a Go template with the sprig functions
+		first. Scenario variables are the template's data and stored objects can be accessed
+		with ref:
+		"""yaml+tmpl
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: {{ .name }}
+		  namespace: default
+		  ownerReferences:
+		  - apiVersion: v1
+		    kind: ConfigMap
+		    name: owner
+		    uid: {{ (ref "owner").metadata.uid }}
+		"""`,
 	parser: ParseDocStringToClientObject,
 }
 
+var Replacement = DocStringArgument{
+	name:        "Replacement",
+	description: `A Kubernetes manifest replacing a resource.`,
+	help: `https://kubernetes.io/docs/concepts/overview/working-with-objects/kubernetes-objects/
+
+		Like a Manifest but the apiVersion, kind, name and namespace can be left out, they
+		default to those of the resource being replaced.`,
+	parser: ParseDocStringToReplacement,
+}
+
 var Script = DocStringArgument{
 	name:        "Script",
 	description: `A script.`,
@@ -192,6 +258,15 @@ var Script = DocStringArgument{
 	parser: UnmarshalDocString,
 }
 
+var HelmValues = DocStringArgument{
+	name:        "Helm Values",
+	description: `The values of a helm chart.`,
+	help: `https://helm.sh/docs/chart_template_guide/values_files/
+
+		The values are yaml like a values file given to helm template with --values.`,
+	parser: UnmarshalDocString,
+}
+
 var MultiLineText = DocStringArgument{
 	name:        "MultiLine Text",
 	description: `A freeform DocString.`,
@@ -243,10 +318,15 @@ func init() {
 		stringParameter{
 			name:        "{filename}",
 			expression:  Anything,
-			description: `Path to a Kubernetes manifest.`,
+			description: `Path to a Kubernetes manifest, a directory of manifests or a glob.`,
 			help: `https://kubernetes.io/docs/concepts/overview/working-with-objects/kubernetes-objects/
 	
-			Can be yaml or json depending on the content type.`,
+			Can be yaml or json depending on the content type. A manifest may contain multiple
+			--- separated documents. The yaml and json files of a directory are read in order.
+
+			Relative paths are resolved against the directory of the feature file, then the
+			--fixtures-dir directories and lastly the working directory. A file:// prefix is
+			optional and embed:path refers to the fixtures bundled by a plugin.`,
 			parser: ParseFileToClientObject,
 		},
 		stringParameter{
@@ -274,7 +354,7 @@ func init() {
 		},
 		stringParameter{
 			name:        "{reference}",
-			expression:  RFC1123,
+			expression:  exprReference,
 			description: `A short hand name for a resource.`,
 			help: `https://kubernetes.io/docs/concepts/overview/working-with-objects/names/
 
@@ -282,7 +362,9 @@ func init() {
 		Action or Outcome step.
 
 		The reference must a name that can be used as a DNS subdomain name as defined in RFC 1123.
-		This is the same Kubernetes requirement for names, i.e. lowercase alphanumeric characters.`,
+		This is the same Kubernetes requirement for names, i.e. lowercase alphanumeric characters.
+
+		A member of a resource set is referred to as set/kind/name, e.g. app/deployment/example.`,
 			parser: ParseClientObject,
 		},
 		stringParameter{
@@ -348,6 +430,13 @@ func init() {
 			help:        `This will match anything.`,
 			parser:      StringParsers.Parse,
 		},
+		stringParameter{
+			name:        "{secret}",
+			expression:  Anything,
+			description: `A sensitive freeform amount of text.`,
+			help:        `This will match anything. The value is masked wherever it is printed or logged.`,
+			parser:      StringParsers.Parse,
+		},
 		stringParameter{
 			name:        "{number}",
 			expression:  exprNumber,
@@ -401,6 +490,103 @@ func init() {
 			help:        `Anything that comes after port.`,
 			parser:      StringParsers.Parse,
 		},
+		stringParameter{
+			name:        "{scope}",
+			expression:  exprScope,
+			description: `The lifetime of a variable or resource.`,
+			help: `One of global (or suite), feature, rule or scenario. Values in a wider scope can be
+		referred to by every scenario within it until it ends.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{cluster}",
+			expression:  RFC1123,
+			description: `The name of a cluster.`,
+			help: `Clusters are named with the --cluster flag of bdk test, e.g. --cluster east=east-context.
+		The cluster of the --kubeconfig and --context flags is called default.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{user}",
+			expression:  UserName,
+			description: `The name of a user to impersonate.`,
+			help: `https://kubernetes.io/docs/reference/access-authn-authz/authentication/#user-impersonation
+
+		The identity of the kubeconfig must be allowed to impersonate the user.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{groups}",
+			expression:  GroupNames,
+			description: `A comma separated list of groups to impersonate.`,
+			help:        `e.g. dev,qa or system:authenticated`,
+			parser:      StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{condition}",
+			expression:  exprCondition,
+			description: `A status condition of a resource.`,
+			help: `https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
+
+		The type and status of the condition, e.g. Ready=True, optionally followed by with reason
+		Provisioned and with message 'text'. The status defaults to True and the message matches
+		any message containing the text. A condition whose observedGeneration is older than the
+		generation of the resource is stale and never matches.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{serviceaccount}",
+			expression:  ServiceAccountRef,
+			description: `A service account to impersonate.`,
+			help: `The namespace and name of the service account separated by a slash, e.g. default/builder.
+		The service account is impersonated with the groups of its namespace.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{subject}",
+			expression:  exprSubject,
+			description: `Who a permission is checked for.`,
+			help: `One of user <name>, user <name> in groups <groups>, group <name> or
+		serviceaccount <namespace>/<name>. Users are checked with the system:authenticated group.`,
+			parser: func(ctx context.Context, s string, t reflect.Type) (reflect.Value, error) {
+				id, err := ParseSubject(s)
+				return reflect.ValueOf(id), err
+			},
+		},
+		stringParameter{
+			name:        "{verb}",
+			expression:  exprVerb,
+			description: `An API verb.`,
+			help: `https://kubernetes.io/docs/reference/access-authn-authz/authorization/#determine-the-request-verb
+
+		e.g. get, list, watch, create, update, patch, delete, deletecollection or * for any verb.`,
+			parser: StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{resource}",
+			expression:  exprResource,
+			description: `An API resource.`,
+			help: `In the form resource[.group][/subresource][ named name], e.g. pods, pods/log,
+		deployments.apps/scale or configmaps named example.`,
+			parser: func(ctx context.Context, s string, t reflect.Type) (reflect.Value, error) {
+				attrs, err := ParseResourceAttributes(s)
+				return reflect.ValueOf(attrs), err
+			},
+		},
+		stringParameter{
+			name:        "{namespace}",
+			expression:  RFC1123,
+			description: `The name of a namespace.`,
+			help:        `https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces/`,
+			parser:      StringParsers.Parse,
+		},
+		stringParameter{
+			name:        "{action}",
+			expression:  exprAction,
+			description: `An action on a resource.`,
+			help:        `One of creating, getting, listing, updating, patching or deleting.`,
+			parser:      StringParsers.Parse,
+		},
 		stringParameter{
 			name:        "{scheme}",
 			expression:  exprURLScheme,
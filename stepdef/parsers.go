@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"math/rand"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -25,8 +25,25 @@ const (
 	CannotParse = "cannot parse '%s' into a %s"
 )
 
-func ParseFileToClientObject(ctx context.Context, path string, targetType reflect.Type) (_ reflect.Value, err error) {
-	manifest, err := os.ReadFile(path)
+func ParseFileToClientObject(ctx context.Context, filename string, targetType reflect.Type) (_ reflect.Value, err error) {
+	// tools which read the files themselves are given the path
+	if targetType.Kind() == reflect.String {
+		name, err := ResolvePath(ctx, filename)
+		return reflect.ValueOf(name), err
+	}
+	fsys, name, err := ResolveFixture(ctx, filename)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if targetType == resourceSetType {
+		set, err := LoadResourceSet(fsys, name)
+		return reflect.ValueOf(set), err
+	}
+	if targetType == resourcesType {
+		r, err := LoadResources(fsys, name)
+		return reflect.ValueOf(r), err
+	}
+	manifest, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return reflect.Value{}, err
 	}
@@ -50,7 +67,15 @@ func ParseClientObject(ctx context.Context, s string, targetType reflect.Type) (
 		return reflect.ValueOf(s), nil
 	}
 
-	u := store.Load[*unstructured.Unstructured](ctx, s)
+	// references to things other than objects such as patches
+	if !targetType.Implements(reflect.TypeOf((*client.Object)(nil)).Elem()) {
+		return StringParsers.Parse(ctx, s, targetType)
+	}
+
+	u, err := store.Load[*unstructured.Unstructured](ctx, s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
 	if targetType == reflect.TypeOf((*unstructured.Unstructured)(nil)) {
 		return reflect.ValueOf(u), nil
 	}
@@ -60,6 +85,11 @@ func ParseClientObject(ctx context.Context, s string, targetType reflect.Type) (
 		return reflect.Value{}, err
 	}
 
+	err = checkKind(o, u.GroupVersionKind())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("reference '%s': %w", s, err)
+	}
+
 	err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, o)
 	if err != nil {
 		return reflect.Value{}, err
@@ -93,6 +123,7 @@ var StringParsers = stringParsers{
 	reflect.TypeOf(float32(0)):  parseFloat32,
 	reflect.TypeOf([]byte(nil)): parseBytes,
 	reflect.TypeOf(false):       parseBool,
+	reflect.TypeOf([]string{}):  parseList,
 
 	//TODO uint uint8 uint16 uint32 uint64 uintptr
 
@@ -101,6 +132,9 @@ var StringParsers = stringParsers{
 	reflect.TypeOf((*unstructured.Unstructured)(nil)):  loadFromStore[*unstructured.Unstructured](),
 	reflect.TypeOf((*corev1.Pod)(nil)):                 parsePod,
 	reflect.TypeOf((*types.GomegaMatcher)(nil)).Elem(): Matchers.ParseMatcher,
+	reflect.TypeOf(store.Scope("")):                    parseScope,
+	reflect.TypeOf(Identity{}):                         parseServiceAccount,
+	reflect.TypeOf(Condition{}):                        parseCondition,
 
 	reflect.TypeOf(client.DryRunAll):                valueIfTrue(client.DryRunAll),
 	reflect.TypeOf(client.FieldOwner("")):           unmarshal[client.FieldOwner],
@@ -110,7 +144,7 @@ var StringParsers = stringParsers{
 	reflect.TypeOf(client.InNamespace("")):          unmarshal[client.InNamespace],
 	reflect.TypeOf(client.Limit(0)):                 unmarshal[client.Limit],
 	reflect.TypeOf(client.ForceOwnership):           valueIfTrue(client.ForceOwnership),
-	reflect.TypeOf((client.Patch)(nil)):             loadFromStore[client.Patch](),
+	reflect.TypeOf((*client.Patch)(nil)).Elem():     loadFromStore[client.Patch](),
 }
 
 type skipValueErr struct{}
@@ -180,10 +214,55 @@ func parseBool(ctx context.Context, s string) (reflect.Value, error) {
 	return reflect.ValueOf(b), nil
 }
 
+// parseList parses a comma separated list
+func parseList(ctx context.Context, s string) (reflect.Value, error) {
+	return reflect.ValueOf(splitList(s)), nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseServiceAccount parses a namespace/name into the Identity of the service account
+func parseServiceAccount(ctx context.Context, s string) (reflect.Value, error) {
+	namespace, name, ok := strings.Cut(s, "/")
+	if !ok || namespace == "" || name == "" {
+		return reflect.Value{}, fmt.Errorf(CannotParse, s, "service account, expected namespace/name")
+	}
+	return reflect.ValueOf(ServiceAccount(namespace, name)), nil
+}
+
+func parseScope(ctx context.Context, s string) (reflect.Value, error) {
+	switch s {
+	case "global", "suite":
+		return reflect.ValueOf(store.SuiteScope), nil
+	case "feature":
+		return reflect.ValueOf(store.FeatureScope), nil
+	case "rule":
+		return reflect.ValueOf(store.RuleScope), nil
+	case "scenario":
+		return reflect.ValueOf(store.ScenarioScope), nil
+	}
+	return reflect.Value{}, fmt.Errorf(CannotParse, s, "scope")
+}
+
 func parsePod(ctx context.Context, s string) (reflect.Value, error) {
-	u := store.Load[*unstructured.Unstructured](ctx, s)
+	u, err := store.Load[*unstructured.Unstructured](ctx, s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
 	pod := &corev1.Pod{}
-	err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(u.Object, pod, false)
+	err = checkKind(pod, u.GroupVersionKind())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("reference '%s': %w", s, err)
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(u.Object, pod, false)
 	if err != nil {
 		return reflect.Value{}, err
 	}
@@ -267,7 +346,11 @@ func contains(s string, a []string) bool {
 
 func loadFromStore[T any]() func(context.Context, string) (reflect.Value, error) {
 	return func(ctx context.Context, s string) (reflect.Value, error) {
-		return reflect.ValueOf(store.Load[T](ctx, s)), nil
+		v, err := store.Load[T](ctx, s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(v), nil
 	}
 }
 
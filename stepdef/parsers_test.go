@@ -30,6 +30,7 @@ var (
 	tPod          = reflect.TypeOf((*corev1.Pod)(nil))
 
 	tClientDryRun = reflect.TypeOf(client.DryRunAll)
+	tScope        = reflect.TypeOf(store.Scope(""))
 )
 
 var u = &unstructured.Unstructured{}
@@ -44,6 +45,7 @@ var _ = Describe("StringParser", Ordered, func() {
 	BeforeAll(func() {
 		u.SetName("my-pod")
 		store.Save(ctxWithStore, "my-ref", u)
+		store.Save(ctxWithStore, "wrong-type", "a string")
 	})
 
 	DescribeTable("Parsing a string into a given type",
@@ -80,5 +82,24 @@ var _ = Describe("StringParser", Ordered, func() {
 		Entry("Matcher", ctxWithNoStore, "say helloworld", tMatcher, gbytes.Say("helloworld")),
 
 		Entry("ClientOption", ctxWithNoStore, "true", tClientDryRun, client.DryRunAll),
+
+		Entry("Scope", ctxWithNoStore, "global", tScope, store.SuiteScope),
+		Entry("Scope", ctxWithNoStore, "feature", tScope, store.FeatureScope),
+	)
+
+	DescribeTable("Parsing an undefined reference",
+		func(t reflect.Type) {
+			_, err := stepdef.StringParsers.Parse(ctxWithStore, "cm", t)
+			Expect(err).Should(MatchError(store.ErrNotFound))
+			Expect(err).Should(MatchError("reference 'cm' is not defined in this scenario"))
+		},
+		Entry("Unstructured", tUnstructured),
+		Entry("Pod", tPod),
+		Entry("Patch", reflect.TypeOf((*client.Patch)(nil)).Elem()),
 	)
+
+	It("should fail to parse a reference of the wrong type", func() {
+		_, err := stepdef.ParseClientObject(ctxWithStore, "wrong-type", tUnstructured)
+		Expect(err).Should(MatchError(store.ErrWrongType))
+	})
 })
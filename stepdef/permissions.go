@@ -0,0 +1,143 @@
+package stepdef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	messages "github.com/cucumber/messages/go/v21"
+	authzv1 "k8s.io/api/authorization/v1"
+)
+
+// Permission is an action which a subject is expected to be allowed or denied
+type Permission struct {
+	// Subject is who the permission is checked for, if it is nil the subject of the step is used
+	Subject *Identity
+	authzv1.ResourceAttributes
+	Allowed bool
+}
+
+func (p Permission) String() string {
+	s := p.Verb + " " + ResourceString(p.ResourceAttributes)
+	if p.Namespace == "" {
+		return s + " cluster-wide"
+	}
+	return s + " in " + p.Namespace
+}
+
+// ParseSubject parses who a permission is checked for:
+//
+//	user alice
+//	user alice in groups dev,qa
+//	group dev
+//	serviceaccount default/builder
+func ParseSubject(s string) (Identity, error) {
+	kind, name, _ := strings.Cut(strings.TrimSpace(s), " ")
+	switch kind {
+	case "user":
+		user, groups, ok := strings.Cut(name, " in groups ")
+		id := Identity{User: user}
+		if ok {
+			id.Groups = splitList(groups)
+		}
+		return id, nil
+	case "group":
+		return Identity{Groups: []string{name}}, nil
+	case "serviceaccount":
+		namespace, name, ok := strings.Cut(name, "/")
+		if ok && namespace != "" && name != "" {
+			return ServiceAccount(namespace, name), nil
+		}
+	}
+	return Identity{}, fmt.Errorf(CannotParse, s, "subject, expected user, group or serviceaccount")
+}
+
+// ParseResourceAttributes parses a resource in the form resource[.group][/subresource][ named name],
+// e.g. pods, pods/log, deployments.apps/scale or configmaps named example
+func ParseResourceAttributes(s string) (authzv1.ResourceAttributes, error) {
+	var attrs authzv1.ResourceAttributes
+	s, attrs.Name, _ = strings.Cut(strings.TrimSpace(s), " named ")
+	s, attrs.Subresource, _ = strings.Cut(s, "/")
+	attrs.Resource, attrs.Group, _ = strings.Cut(s, ".")
+	if attrs.Resource == "" {
+		return attrs, fmt.Errorf(CannotParse, s, "resource")
+	}
+	return attrs, nil
+}
+
+// ResourceString is the inverse of ParseResourceAttributes
+func ResourceString(attrs authzv1.ResourceAttributes) string {
+	s := attrs.Resource
+	if attrs.Group != "" {
+		s += "." + attrs.Group
+	}
+	if attrs.Subresource != "" {
+		s += "/" + attrs.Subresource
+	}
+	if attrs.Name != "" {
+		s += " named " + attrs.Name
+	}
+	return s
+}
+
+// ParsePermissions parses a table of permissions into a []Permission. The header names
+// the columns: verb, resource and allowed are required, subject and namespace are
+// optional. A permission without a namespace is checked cluster-wide.
+func ParsePermissions(ctx context.Context, dt *messages.DataTable, targetType reflect.Type) (reflect.Value, error) {
+	if targetType != reflect.TypeOf([]Permission{}) {
+		return reflect.Value{}, fmt.Errorf(CannotParse, "DataTable (step argument)", targetType.String())
+	}
+	if dt == nil || len(dt.Rows) < 2 {
+		return reflect.Value{}, errors.New("expected a table with a header and at least one permission")
+	}
+
+	columns := map[string]int{}
+	for i, cell := range dt.Rows[0].Cells {
+		columns[strings.ToLower(strings.TrimSpace(cell.Value))] = i
+	}
+	for _, required := range []string{"verb", "resource", "allowed"} {
+		if _, ok := columns[required]; !ok {
+			return reflect.Value{}, fmt.Errorf("permissions table has no %s column", required)
+		}
+	}
+
+	var permissions []Permission
+	for _, row := range dt.Rows[1:] {
+		cell := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row.Cells) {
+				return ""
+			}
+			return strings.TrimSpace(row.Cells[i].Value)
+		}
+
+		attrs, err := ParseResourceAttributes(cell("resource"))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		attrs.Verb = cell("verb")
+		attrs.Namespace = cell("namespace")
+		p := Permission{ResourceAttributes: attrs}
+
+		switch strings.ToLower(cell("allowed")) {
+		case "yes", "true", "allowed":
+			p.Allowed = true
+		case "no", "false", "denied":
+		default:
+			return reflect.Value{}, fmt.Errorf(CannotParse, cell("allowed"), "allowed, expected yes or no")
+		}
+
+		if subject := cell("subject"); subject != "" {
+			id, err := ParseSubject(subject)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			p.Subject = &id
+		}
+
+		permissions = append(permissions, p)
+	}
+	return reflect.ValueOf(permissions), nil
+}
@@ -0,0 +1,70 @@
+package stepdef_test
+
+import (
+	"context"
+	"reflect"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	authzv1 "k8s.io/api/authorization/v1"
+)
+
+var _ = Describe("Permissions", func() {
+	DescribeTable("parsing subjects",
+		func(s string, expected stepdef.Identity) {
+			Expect(stepdef.ParseSubject(s)).Should(Equal(expected))
+		},
+		Entry("a user", "user alice", stepdef.Identity{User: "alice"}),
+		Entry("a user in groups", "user alice in groups dev,qa", stepdef.Identity{User: "alice", Groups: []string{"dev", "qa"}}),
+		Entry("a group", "group dev", stepdef.Identity{Groups: []string{"dev"}}),
+		Entry("a service account", "serviceaccount default/builder", stepdef.ServiceAccount("default", "builder")),
+	)
+
+	It("should not parse other subjects", func() {
+		_, err := stepdef.ParseSubject("robot r2d2")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	DescribeTable("parsing resources",
+		func(s string, expected authzv1.ResourceAttributes) {
+			attrs, err := stepdef.ParseResourceAttributes(s)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(attrs).Should(Equal(expected))
+			Expect(stepdef.ResourceString(attrs)).Should(Equal(s))
+		},
+		Entry("a core resource", "pods", authzv1.ResourceAttributes{Resource: "pods"}),
+		Entry("a subresource", "pods/log", authzv1.ResourceAttributes{Resource: "pods", Subresource: "log"}),
+		Entry("a group", "deployments.apps/scale", authzv1.ResourceAttributes{Resource: "deployments", Group: "apps", Subresource: "scale"}),
+		Entry("a name", "configmaps named example", authzv1.ResourceAttributes{Resource: "configmaps", Name: "example"}),
+	)
+
+	It("should parse a permission matrix", func() {
+		table := &messages.DataTable{Rows: []*messages.TableRow{
+			{Cells: []*messages.TableCell{{Value: "subject"}, {Value: "verb"}, {Value: "resource"}, {Value: "namespace"}, {Value: "allowed"}}},
+			{Cells: []*messages.TableCell{{Value: "group dev"}, {Value: "get"}, {Value: "pods/log"}, {Value: "default"}, {Value: "yes"}}},
+			{Cells: []*messages.TableCell{{Value: ""}, {Value: "delete"}, {Value: "namespaces"}, {Value: ""}, {Value: "no"}}},
+		}}
+		v, err := stepdef.ParsePermissions(context.Background(), table, reflect.TypeOf([]stepdef.Permission{}))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		permissions := v.Interface().([]stepdef.Permission)
+		Expect(permissions).Should(HaveLen(2))
+		Expect(*permissions[0].Subject).Should(Equal(stepdef.Identity{Groups: []string{"dev"}}))
+		Expect(permissions[0].Allowed).Should(BeTrue())
+		Expect(permissions[0].String()).Should(Equal("get pods/log in default"))
+		Expect(permissions[1].Subject).Should(BeNil())
+		Expect(permissions[1].Allowed).Should(BeFalse())
+		Expect(permissions[1].String()).Should(Equal("delete namespaces cluster-wide"))
+	})
+
+	It("should require the verb, resource and allowed columns", func() {
+		table := &messages.DataTable{Rows: []*messages.TableRow{
+			{Cells: []*messages.TableCell{{Value: "verb"}, {Value: "resource"}}},
+			{Cells: []*messages.TableCell{{Value: "get"}, {Value: "pods"}}},
+		}}
+		_, err := stepdef.ParsePermissions(context.Background(), table, reflect.TypeOf([]stepdef.Permission{}))
+		Expect(err).Should(MatchError("permissions table has no allowed column"))
+	})
+})
@@ -0,0 +1,57 @@
+package stepdef
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RenderKustomization builds the kustomization in dir with kustomize, or kubectl kustomize if
+// kustomize is not installed
+func RenderKustomization(ctx context.Context, dir string) (ResourceSet, error) {
+	if _, err := exec.LookPath("kustomize"); err == nil {
+		return render(ctx, "kustomize", "build", dir)
+	}
+	return render(ctx, "kubectl", "kustomize", dir)
+}
+
+// RenderHelmChart renders a local chart, a directory or packaged chart, with helm template.
+// The values are yaml, no values renders the defaults of the chart.
+func RenderHelmChart(ctx context.Context, release, chart string, values []byte) (ResourceSet, error) {
+	args := []string{"template", release, chart}
+	if len(bytes.TrimSpace(values)) > 0 {
+		dir, err := os.MkdirTemp("", "bdk-helm-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "values.yaml")
+		if err := os.WriteFile(file, values, 0o600); err != nil {
+			return nil, err
+		}
+		args = append(args, "--values", file)
+	}
+	return render(ctx, "helm", args...)
+}
+
+// render runs a tool which writes manifests to stdout
+func render(ctx context.Context, name string, args ...string) (ResourceSet, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	set, err := ParseResourceSet(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s %s rendered an invalid manifest: %w", name, strings.Join(args, " "), err)
+	}
+	return set, nil
+}
@@ -0,0 +1,57 @@
+package stepdef_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+var _ = Describe("Render", func() {
+	var bin string
+
+	// tool installs a fake tool which checks its arguments and prints manifests
+	tool := func(name, script string) {
+		Expect(os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0o755)).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		bin = GinkgoT().TempDir()
+		// only the fake tools and the shell utilities they use are found
+		GinkgoT().Setenv("PATH", bin+":/bin:/usr/bin")
+	})
+
+	It("should build a kustomization", func() {
+		tool("kustomize", "[ \"$1 $2\" = \"build overlays/test\" ] || exit 1\n"+
+			"cat <<'MANIFEST'\n"+namespace+"---\n"+configMap+"MANIFEST\n")
+		set, err := stepdef.RenderKustomization(context.Background(), "overlays/test")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(set.Members("app")).Should(Equal([]string{"app/namespace/example", "app/configmap/example"}))
+	})
+
+	It("should fall back to kubectl kustomize", func() {
+		tool("kubectl", "[ \"$1 $2\" = \"kustomize overlays/test\" ] || exit 1\n"+
+			"cat <<'MANIFEST'\n"+configMap+"MANIFEST\n")
+		set, err := stepdef.RenderKustomization(context.Background(), "overlays/test")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(set).Should(HaveLen(1))
+	})
+
+	It("should template a helm chart with values", func() {
+		tool("helm", "[ \"$1 $2 $3 $4\" = \"template app ./chart --values\" ] || exit 1\n"+
+			"read -r values < \"$5\"\n"+
+			"cat <<MANIFEST\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  ${values}\nMANIFEST\n")
+		set, err := stepdef.RenderHelmChart(context.Background(), "app", "./chart", []byte("name: from-values\n"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(set.Members("app")).Should(Equal([]string{"app/configmap/from-values"}))
+	})
+
+	It("should report the errors of the tool", func() {
+		tool("helm", "echo 'Error: chart not found' >&2; exit 1\n")
+		_, err := stepdef.RenderHelmChart(context.Background(), "app", "./missing", nil)
+		Expect(err).Should(MatchError(ContainSubstring("helm template app ./missing: exit status 1: Error: chart not found")))
+	})
+})
@@ -0,0 +1,219 @@
+package stepdef
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ResourceSet is a named group of resources, e.g. from a manifest of multiple documents or a
+// directory of manifests. Steps referring to a set act on every member.
+type ResourceSet []*unstructured.Unstructured
+
+var resourceSetType = reflect.TypeOf(ResourceSet{})
+
+// Resources are the resources of a file, directory, glob or DocString. Document is true if they
+// were given as a single document of a single file or DocString, such resources are saved as an
+// object rather than a set.
+type Resources struct {
+	ResourceSet
+	Document bool
+}
+
+var resourcesType = reflect.TypeOf(Resources{})
+
+// MemberRef is the reference of a member of a set, i.e. set/kind/name
+func MemberRef(set string, obj *unstructured.Unstructured) string {
+	return set + "/" + strings.ToLower(obj.GetKind()) + "/" + obj.GetName()
+}
+
+// SaveResourceSet saves the set and each of its members so that members can be referred to
+// individually as set/kind/name
+func SaveResourceSet(ctx context.Context, name string, set ResourceSet) error {
+	refs := map[string]bool{}
+	for _, obj := range set {
+		ref := MemberRef(name, obj)
+		if refs[ref] {
+			return fmt.Errorf("resource set %s has more than one %s", name, ref)
+		}
+		refs[ref] = true
+	}
+
+	store.Save(ctx, name, set)
+	for _, obj := range set {
+		store.Save(ctx, MemberRef(name, obj), obj)
+	}
+	return nil
+}
+
+// Members returns the references of the members of the set
+func (s ResourceSet) Members(name string) []string {
+	refs := make([]string, len(s))
+	for i, obj := range s {
+		refs[i] = MemberRef(name, obj)
+	}
+	return refs
+}
+
+// ParseResourceSet parses a manifest of one or more --- separated documents. Empty documents are
+// skipped and the items of a List are members of the set.
+func ParseResourceSet(manifest []byte) (ResourceSet, error) {
+	r, err := ParseResources(manifest)
+	return r.ResourceSet, err
+}
+
+// ParseResources parses a manifest like ParseResourceSet, the resources are a Document if the
+// manifest has one document which is not a List.
+func ParseResources(manifest []byte) (Resources, error) {
+	set := ResourceSet{}
+	documents := 0
+	lists := 0
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Resources{}, err
+		}
+		if isEmptyDocument(doc) {
+			continue
+		}
+		documents++
+
+		v, err := unmarshalToClientObject(doc, reflect.TypeOf((*unstructured.Unstructured)(nil)))
+		if err != nil {
+			return Resources{}, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		obj := v.Interface().(*unstructured.Unstructured)
+		if !obj.IsList() {
+			set = append(set, obj)
+			continue
+		}
+		lists++
+		err = obj.EachListItem(func(item runtime.Object) error {
+			set = append(set, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return Resources{}, fmt.Errorf("document %d: %w", i+1, err)
+		}
+	}
+	if len(set) == 0 {
+		return Resources{}, errors.New("manifest has no resources")
+	}
+	return Resources{ResourceSet: set, Document: documents == 1 && lists == 0}, nil
+}
+
+// LoadResourceSet reads the manifests of a file, a directory or a glob. The YAML and JSON
+// files of a directory are read in lexical order, subdirectories are not read.
+func LoadResourceSet(fsys fs.FS, name string) (ResourceSet, error) {
+	files, err := manifestFiles(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	set := ResourceSet{}
+	for _, file := range files {
+		manifest, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		s, err := ParseResourceSet(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		set = append(set, s...)
+	}
+	return set, nil
+}
+
+// LoadResources reads the manifests of a file, a directory or a glob like LoadResourceSet, the
+// resources are a Document if the name is a file of one document
+func LoadResources(fsys fs.FS, name string) (Resources, error) {
+	if info, err := fs.Stat(fsys, name); err == nil && !info.IsDir() {
+		manifest, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return Resources{}, err
+		}
+		r, err := ParseResources(manifest)
+		if err != nil {
+			return Resources{}, fmt.Errorf("%s: %w", name, err)
+		}
+		return r, nil
+	}
+	set, err := LoadResourceSet(fsys, name)
+	return Resources{ResourceSet: set}, err
+}
+
+func manifestFiles(fsys fs.FS, name string) ([]string, error) {
+	info, err := fs.Stat(fsys, name)
+	if err == nil && !info.IsDir() {
+		return []string{name}, nil
+	}
+
+	var files []string
+	if err == nil {
+		// fs.ReadDir returns the entries in lexical order
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isManifestFile(e.Name()) {
+				files = append(files, path.Join(name, e.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("directory %s has no manifests", name)
+		}
+		return files, nil
+	}
+
+	matches, globErr := fs.Glob(fsys, name)
+	if globErr != nil {
+		return nil, globErr
+	}
+	for _, m := range matches {
+		if info, err := fs.Stat(fsys, m); err == nil && !info.IsDir() {
+			files = append(files, m)
+		}
+	}
+	if len(files) == 0 {
+		// not a glob, report why the file could not be read
+		return nil, err
+	}
+	return files, nil
+}
+
+func isManifestFile(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// isEmptyDocument is true for documents of only whitespace and comments
+func isEmptyDocument(doc []byte) bool {
+	for _, line := range strings.Split(string(doc), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
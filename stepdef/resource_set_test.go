@@ -0,0 +1,139 @@
+package stepdef_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const namespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: example
+`
+
+const configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+  namespace: example
+`
+
+var _ = Describe("ResourceSet", func() {
+	names := func(set stepdef.ResourceSet) []string {
+		return set.Members("app")
+	}
+
+	It("should parse multiple documents", func() {
+		set, err := stepdef.ParseResourceSet([]byte("---\n# comment\n---\n" + namespace + "---\n" + configMap + "---\n"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(names(set)).Should(Equal([]string{"app/namespace/example", "app/configmap/example"}))
+	})
+
+	It("should add the items of a list", func() {
+		set, err := stepdef.ParseResourceSet([]byte(`apiVersion: v1
+kind: List
+metadata:
+  name: list
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: b
+`))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(names(set)).Should(Equal([]string{"app/configmap/a", "app/configmap/b"}))
+	})
+
+	It("should fail on invalid documents", func() {
+		_, err := stepdef.ParseResourceSet([]byte(namespace + "---\nkind: ConfigMap\n"))
+		Expect(err).Should(MatchError(ContainSubstring("document 2")))
+
+		_, err = stepdef.ParseResourceSet([]byte("---\n"))
+		Expect(err).Should(MatchError("manifest has no resources"))
+	})
+
+	It("should parse a DocString into a set", func() {
+		v, err := stepdef.ParseDocStringToClientObject(context.Background(), &messages.DocString{Content: namespace + "---\n" + configMap}, reflect.TypeOf(stepdef.ResourceSet{}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface()).Should(HaveLen(2))
+	})
+
+	DescribeTable("parsing a DocString into resources",
+		func(content string, document bool) {
+			v, err := stepdef.ParseDocStringToClientObject(context.Background(), &messages.DocString{Content: content}, reflect.TypeOf(stepdef.Resources{}))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(v.Interface().(stepdef.Resources).Document).Should(Equal(document))
+		},
+		Entry("one document", configMap, true),
+		Entry("multiple documents", namespace+"---\n"+configMap, false),
+		Entry("a list of one item", "apiVersion: v1\nkind: List\nmetadata:\n  name: list\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: example\n", false),
+	)
+
+	Context("loading files", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "b-cm.yaml"), []byte(configMap), 0o644)).Should(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "a-ns.yml"), []byte(namespace), 0o644)).Should(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o644)).Should(Succeed())
+		})
+
+		It("should load the manifests of a directory in order", func() {
+			set, err := stepdef.LoadResourceSet(os.DirFS(dir), ".")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(names(set)).Should(Equal([]string{"app/namespace/example", "app/configmap/example"}))
+		})
+
+		It("should load a glob", func() {
+			set, err := stepdef.LoadResourceSet(os.DirFS(dir), "*.yaml")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(names(set)).Should(Equal([]string{"app/configmap/example"}))
+		})
+
+		It("should load a single document file as a document", func() {
+			r, err := stepdef.LoadResources(os.DirFS(dir), "b-cm.yaml")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Document).Should(BeTrue())
+			Expect(r.ResourceSet).Should(HaveLen(1))
+		})
+
+		It("should load a glob of one file as a set", func() {
+			r, err := stepdef.LoadResources(os.DirFS(dir), "*.yaml")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(r.Document).Should(BeFalse())
+			Expect(names(r.ResourceSet)).Should(Equal([]string{"app/configmap/example"}))
+		})
+
+		It("should fail if nothing matches", func() {
+			_, err := stepdef.LoadResourceSet(os.DirFS(dir), "missing.yaml")
+			Expect(err).Should(MatchError(os.ErrNotExist))
+		})
+	})
+
+	It("should save each member", func() {
+		ctx := store.NewStoreFor(context.Background())
+		set, err := stepdef.ParseResourceSet([]byte(namespace + "---\n" + configMap))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(stepdef.SaveResourceSet(ctx, "app", set)).Should(Succeed())
+
+		cm, err := store.Load[*unstructured.Unstructured](ctx, "app/configmap/example")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cm).Should(BeIdenticalTo(set[1]))
+
+		Expect(stepdef.SaveResourceSet(ctx, "twice", append(set, set[1]))).Should(MatchError(ContainSubstring("more than one twice/configmap/example")))
+	})
+})
@@ -0,0 +1,24 @@
+package stepdef
+
+import (
+	"context"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+type restConfigKey struct{}
+
+// WithRestConfig returns a context with the rest.Config which steps use to talk to the cluster
+func WithRestConfig(ctx context.Context, cfg *rest.Config) context.Context {
+	return context.WithValue(ctx, restConfigKey{}, cfg)
+}
+
+// RestConfigFrom returns the rest.Config of the context or, if there is none, the
+// config loaded from the environment in the same way as controller-runtime
+func RestConfigFrom(ctx context.Context) (*rest.Config, error) {
+	if cfg, ok := ctx.Value(restConfigKey{}).(*rest.Config); ok && cfg != nil {
+		return cfg, nil
+	}
+	return config.GetConfig()
+}
@@ -0,0 +1,19 @@
+package stepdef_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("RestConfig", func() {
+	It("should return the rest.Config of the context", func() {
+		cfg := &rest.Config{Host: "https://example.com", QPS: 5, Burst: 10}
+		ctx := stepdef.WithRestConfig(context.Background(), cfg)
+
+		Expect(stepdef.RestConfigFrom(ctx)).Should(BeIdenticalTo(cfg))
+	})
+})
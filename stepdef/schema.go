@@ -0,0 +1,475 @@
+package stepdef
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// Schemas are the OpenAPI schemas used to validate manifests before any step runs
+type Schemas interface {
+	// Schema returns the schema of a kind and the schemas it refers to. A nil schema
+	// means the kind cannot be validated.
+	Schema(gvk schema.GroupVersionKind) (*spec.Schema, map[string]*spec.Schema, error)
+}
+
+// openAPISchemas are schemas from OpenAPI v3 documents and CustomResourceDefinitions
+type openAPISchemas struct {
+	components map[string]*spec.Schema
+	kinds      map[schema.GroupVersionKind]*spec.Schema
+}
+
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{
+		components: map[string]*spec.Schema{},
+		kinds:      map[schema.GroupVersionKind]*spec.Schema{},
+	}
+}
+
+func (s *openAPISchemas) Schema(gvk schema.GroupVersionKind) (*spec.Schema, map[string]*spec.Schema, error) {
+	return s.kinds[gvk], s.components, nil
+}
+
+// addDocument adds the schemas of an OpenAPI v3 document, kinds are found by their
+// x-kubernetes-group-version-kind extension
+func (s *openAPISchemas) addDocument(b []byte) error {
+	doc := &spec3.OpenAPI{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return err
+	}
+	if doc.Components == nil {
+		return nil
+	}
+	for name, component := range doc.Components.Schemas {
+		s.components[name] = component
+
+		var gvks []schema.GroupVersionKind
+		if err := component.Extensions.GetObject("x-kubernetes-group-version-kind", &gvks); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		for _, gvk := range gvks {
+			s.kinds[gvk] = component
+		}
+	}
+	return nil
+}
+
+// addCRD adds the schema of each version of a CustomResourceDefinition
+func (s *openAPISchemas) addCRD(crd *unstructured.Unstructured) error {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(version, "name")
+		openAPIV3Schema, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if !found {
+			continue
+		}
+
+		b, err := json.Marshal(openAPIV3Schema)
+		if err != nil {
+			return err
+		}
+		component := &spec.Schema{}
+		if err := json.Unmarshal(b, component); err != nil {
+			return fmt.Errorf("CustomResourceDefinition %s version %s: %w", crd.GetName(), name, err)
+		}
+		s.kinds[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = component
+	}
+	return nil
+}
+
+// LoadSchemas reads CustomResourceDefinitions and OpenAPI v3 documents, such as the output of
+// kubectl get --raw /openapi/v3/apis/apps/v1, from files, directories or globs. Kinds without a
+// schema are not validated.
+func LoadSchemas(paths []string) (Schemas, error) {
+	schemas := newOpenAPISchemas()
+	for _, p := range paths {
+		fsys, name, err := osFixture(p)
+		if err != nil {
+			return nil, err
+		}
+		files, err := manifestFiles(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			if err := schemas.addFile(b); err != nil {
+				return nil, fmt.Errorf("%s: %w", p, err)
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// addFile adds an OpenAPI v3 document or the CustomResourceDefinitions of a manifest
+func (s *openAPISchemas) addFile(b []byte) error {
+	var document struct {
+		OpenAPI string `json:"openapi"`
+	}
+	if json.Unmarshal(b, &document) == nil && document.OpenAPI != "" {
+		return s.addDocument(b)
+	}
+
+	set, err := ParseResourceSet(b)
+	if err != nil {
+		return err
+	}
+	for _, obj := range set {
+		if obj.GetKind() == "CustomResourceDefinition" {
+			if err := s.addCRD(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// clusterSchemas fetches the OpenAPI v3 document of a group version the first time one of
+// its kinds is validated
+type clusterSchemas struct {
+	client openapi.Client
+
+	mu      sync.Mutex
+	paths   map[string]openapi.GroupVersion
+	fetched map[string]bool
+	*openAPISchemas
+}
+
+// ClusterSchemas returns the schemas served by the cluster, e.g. from
+// clientset.Discovery().OpenAPIV3()
+func ClusterSchemas(client openapi.Client) Schemas {
+	return &clusterSchemas{
+		client:         client,
+		fetched:        map[string]bool{},
+		openAPISchemas: newOpenAPISchemas(),
+	}
+}
+
+func (s *clusterSchemas) Schema(gvk schema.GroupVersionKind) (*spec.Schema, map[string]*spec.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paths == nil {
+		paths, err := s.client.Paths()
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not discover the OpenAPI schemas of the cluster: %w", err)
+		}
+		s.paths = paths
+	}
+
+	path := "apis/" + gvk.GroupVersion().String()
+	if gvk.Group == "" {
+		path = "api/" + gvk.Version
+	}
+	if !s.fetched[path] {
+		gv, ok := s.paths[path]
+		if !ok {
+			// a feature may install the CustomResourceDefinition of the kind itself
+			return nil, s.components, nil
+		}
+		b, err := gv.Schema("application/json")
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not fetch the OpenAPI schema of %s: %w", gvk.GroupVersion(), err)
+		}
+		if err := s.addDocument(b); err != nil {
+			return nil, nil, fmt.Errorf("OpenAPI schema of %s: %w", gvk.GroupVersion(), err)
+		}
+		s.fetched[path] = true
+	}
+
+	return s.kinds[gvk], s.components, nil
+}
+
+// crdSchemas are the schemas of CustomResourceDefinitions found in manifests, falling back to
+// other schemas for the remaining kinds
+type crdSchemas struct {
+	crds *openAPISchemas
+	Schemas
+}
+
+// WithCRDs returns schemas which validate custom resources against the CustomResourceDefinitions
+// of the manifests, features often install a CustomResourceDefinition and then create its
+// custom resources. Other kinds are validated against schemas.
+func WithCRDs(schemas Schemas, manifests ...[]byte) (Schemas, error) {
+	crds := newOpenAPISchemas()
+	for _, manifest := range manifests {
+		set, err := ParseResourceSet(manifest)
+		if err != nil {
+			// reported when the manifest is validated
+			continue
+		}
+		for _, obj := range set {
+			if obj.GetKind() != "CustomResourceDefinition" {
+				continue
+			}
+			if err := crds.addCRD(obj); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &crdSchemas{crds: crds, Schemas: schemas}, nil
+}
+
+func (s *crdSchemas) Schema(gvk schema.GroupVersionKind) (*spec.Schema, map[string]*spec.Schema, error) {
+	if kind, ok := s.crds.kinds[gvk]; ok {
+		return kind, s.crds.components, nil
+	}
+	return s.Schemas.Schema(gvk)
+}
+
+// FieldError is an invalid field of a manifest. Line is the line of the field in the manifest.
+type FieldError struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidateManifest validates each document of a manifest against its schema. It returns
+// unknown fields and fields of the wrong type. Kinds without a schema are not validated.
+func ValidateManifest(manifest []byte, schemas Schemas) ([]FieldError, error) {
+	var errs []FieldError
+	decoder := yaml.NewDecoder(strings.NewReader(string(manifest)))
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+
+		gvk := schema.FromAPIVersionAndKind(scalar(root, "apiVersion"), scalar(root, "kind"))
+		if gvk.Kind == "" {
+			continue
+		}
+		s, refs, err := schemas.Schema(gvk)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+
+		v := &schemaValidator{refs: refs}
+		v.validate(root, s, "")
+		errs = append(errs, v.errs...)
+	}
+	return errs, nil
+}
+
+// scalar returns the value of a scalar field of a mapping
+func scalar(mapping *yaml.Node, key string) string {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key && mapping.Content[i+1].Kind == yaml.ScalarNode {
+			return mapping.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+type schemaValidator struct {
+	refs map[string]*spec.Schema
+	errs []FieldError
+}
+
+func (v *schemaValidator) errorf(node *yaml.Node, path, format string, a ...any) {
+	v.errs = append(v.errs, FieldError{Line: node.Line, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// resolve follows references, Kubernetes wraps references to other kinds in an allOf
+func (v *schemaValidator) resolve(s *spec.Schema) *spec.Schema {
+	for s != nil {
+		if ref := s.Ref.String(); ref != "" {
+			s = v.refs[ref[strings.LastIndex(ref, "/")+1:]]
+			continue
+		}
+		if len(s.AllOf) == 1 && len(s.Type) == 0 && len(s.Properties) == 0 {
+			s = &s.AllOf[0]
+			continue
+		}
+		return s
+	}
+	return nil
+}
+
+func (v *schemaValidator) validate(node *yaml.Node, s *spec.Schema, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	s = v.resolve(s)
+	if s == nil || node.Tag == "!!null" {
+		return
+	}
+
+	if intOrString, _ := s.Extensions.GetBool("x-kubernetes-int-or-string"); intOrString {
+		if node.Tag != "!!int" && node.Tag != "!!str" {
+			v.errorf(node, path, "expected an integer or a string but found %s", describe(node))
+		}
+		return
+	}
+
+	typ := ""
+	if len(s.Type) > 0 {
+		typ = s.Type[0]
+	} else if len(s.Properties) > 0 {
+		typ = "object"
+	}
+
+	switch typ {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			v.errorf(node, path, "expected an object but found %s", describe(node))
+			return
+		}
+		v.validateObject(node, s, path)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			v.errorf(node, path, "expected an array but found %s", describe(node))
+			return
+		}
+		if s.Items == nil || s.Items.Schema == nil {
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(item, s.Items.Schema, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if node.Tag != "!!str" && node.Tag != "!!timestamp" && node.Tag != "!!binary" {
+			v.errorf(node, path, "expected a string but found %s", describe(node))
+		}
+	case "integer":
+		if node.Tag != "!!int" {
+			v.errorf(node, path, "expected an integer but found %s", describe(node))
+		}
+	case "number":
+		if node.Tag != "!!int" && node.Tag != "!!float" {
+			v.errorf(node, path, "expected a number but found %s", describe(node))
+		}
+	case "boolean":
+		if node.Tag != "!!bool" {
+			v.errorf(node, path, "expected a boolean but found %s", describe(node))
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(node *yaml.Node, s *spec.Schema, path string) {
+	preserveUnknown, _ := s.Extensions.GetBool("x-kubernetes-preserve-unknown-fields")
+	embedded, _ := s.Extensions.GetBool("x-kubernetes-embedded-resource")
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		field := path + "." + key.Value
+
+		if property, ok := s.Properties[key.Value]; ok {
+			v.validate(value, &property, field)
+			continue
+		}
+		if s.AdditionalProperties != nil {
+			if s.AdditionalProperties.Schema != nil {
+				v.validate(value, s.AdditionalProperties.Schema, field)
+			}
+			continue
+		}
+		// every object has these fields, the schemas of CRDs do not always list them
+		if (path == "" || embedded) && (key.Value == "apiVersion" || key.Value == "kind" || key.Value == "metadata") {
+			continue
+		}
+		if preserveUnknown || len(s.Properties) == 0 {
+			continue
+		}
+
+		if similar := similarField(key.Value, s.Properties); similar != "" {
+			v.errorf(key, field, "unknown field, did you mean %q?", similar)
+		} else {
+			v.errorf(key, field, "unknown field")
+		}
+	}
+}
+
+// similarField returns the property most like a misspelled field, if one is close enough
+func similarField(field string, properties map[string]spec.Schema) string {
+	best, bestDistance := "", 3
+	for name := range properties {
+		if strings.EqualFold(name, field) {
+			return name
+		}
+		if d := editDistance(strings.ToLower(field), strings.ToLower(name)); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if bestDistance > 2 {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return fmt.Sprintf("the integer %s", node.Value)
+	case "!!float":
+		return fmt.Sprintf("the number %s", node.Value)
+	case "!!bool":
+		return fmt.Sprintf("the boolean %s", node.Value)
+	}
+	return fmt.Sprintf("the string %q", node.Value)
+}
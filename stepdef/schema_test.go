@@ -0,0 +1,165 @@
+package stepdef_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/client-go/openapi/openapitest"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+              port:
+                x-kubernetes-int-or-string: true
+              config:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+`
+
+var _ = Describe("Schema validation", func() {
+	Context("against the schemas of a cluster", func() {
+		var schemas stepdef.Schemas
+
+		BeforeEach(func() {
+			schemas = stepdef.ClusterSchemas(openapitest.NewEmbeddedFileClient())
+		})
+
+		It("should accept a valid manifest", func() {
+			errs, err := stepdef.ValidateManifest([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  labels:
+    app: example
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: example
+  template:
+    metadata:
+      labels:
+        app: example
+    spec:
+      containers:
+      - name: app
+        image: nginx
+        ports:
+        - containerPort: 80
+        resources:
+          limits:
+            cpu: 100m
+            memory: 1Gi
+`), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(BeEmpty())
+		})
+
+		It("should report unknown fields and wrong types with their line", func() {
+			errs, err := stepdef.ValidateManifest([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+data:
+  foo: bar
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  replica: 1
+  paused: "true"
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+        ports:
+        - containerPort: eighty
+`), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(Equal([]stepdef.FieldError{
+				{Line: 13, Path: ".spec.replica", Message: `unknown field, did you mean "replicas"?`},
+				{Line: 14, Path: ".spec.paused", Message: `expected a boolean but found the string "true"`},
+				{Line: 21, Path: ".spec.template.spec.containers[0].ports[0].containerPort", Message: `expected an integer but found the string "eighty"`},
+			}))
+		})
+
+		It("should not validate kinds which are not served", func() {
+			errs, err := stepdef.ValidateManifest([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: example\nspec:\n  colour: red\n"), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(BeEmpty())
+		})
+
+		It("should validate custom resources against the CustomResourceDefinitions of the manifests", func() {
+			schemas, err := stepdef.WithCRDs(schemas, []byte(widgetCRD))
+			Expect(err).ShouldNot(HaveOccurred())
+			errs, err := stepdef.ValidateManifest([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: example\nspec:\n  colour: red\n"), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(Equal([]stepdef.FieldError{
+				{Line: 6, Path: ".spec.colour", Message: "unknown field"},
+			}))
+		})
+	})
+
+	Context("against local schema files", func() {
+		var schemas stepdef.Schemas
+
+		BeforeEach(func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "widgets.yaml"), []byte(widgetCRD), 0o644)).Should(Succeed())
+			var err error
+			schemas, err = stepdef.LoadSchemas([]string{dir})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should validate custom resources", func() {
+			errs, err := stepdef.ValidateManifest([]byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: example
+spec:
+  size: 3
+  port: http
+  config:
+    anything: goes
+  colour: red
+`), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(Equal([]stepdef.FieldError{
+				{Line: 10, Path: ".spec.colour", Message: "unknown field"},
+			}))
+		})
+
+		It("should not validate kinds without a schema", func() {
+			errs, err := stepdef.ValidateManifest([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: example\nfoo: bar\n"), schemas)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(errs).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package stepdef
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Scheme has every API type which steps can take as a typed object and which the
+// clients can send. It starts with the client-go types, plugins add their own types
+// with AddToScheme, usually from an init function:
+//
+//	func init() {
+//		utilruntime.Must(stepdef.AddToScheme(myv1.AddToScheme))
+//	}
+var Scheme = runtime.NewScheme()
+
+func init() {
+	if err := clientgoscheme.AddToScheme(Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// AddToScheme adds API types to the Scheme, e.g. the AddToScheme of a generated API package
+func AddToScheme(builders ...func(*runtime.Scheme) error) error {
+	builder := runtime.NewSchemeBuilder(builders...)
+	return builder.AddToScheme(Scheme)
+}
+
+// RegisterParser registers a parser for T in StringParsers so that steps can take a T
+// as an argument
+func RegisterParser[T any](parse func(ctx context.Context, s string) (T, error)) {
+	StringParsers[reflect.TypeOf((*T)(nil)).Elem()] = func(ctx context.Context, s string) (reflect.Value, error) {
+		v, err := parse(ctx, s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(v), nil
+	}
+}
+
+// checkKind returns an error if the Scheme knows the type of o and gvk is not one of
+// its kinds. Types which are not in the Scheme, such as unstructured, and objects
+// without a kind are not checked.
+func checkKind(o runtime.Object, gvk schema.GroupVersionKind) error {
+	if gvk.Empty() {
+		return nil
+	}
+	kinds, _, err := Scheme.ObjectKinds(o)
+	if err != nil || len(kinds) == 0 {
+		return nil
+	}
+	for _, kind := range kinds {
+		if kind == gvk {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected a %s but it is a %s", kindString(kinds[0]), kindString(gvk))
+}
+
+func kindString(gvk schema.GroupVersionKind) string {
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return fmt.Sprintf("%s %s", apiVersion, kind)
+}
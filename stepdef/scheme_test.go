@@ -0,0 +1,109 @@
+package stepdef_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var databaseGV = schema.GroupVersion{Group: "example.com", Version: "v1"}
+
+// Database is an API type a plugin could register
+type Database struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              DatabaseSpec `json:"spec"`
+}
+
+type DatabaseSpec struct {
+	Engine string `json:"engine"`
+}
+
+func (d *Database) DeepCopyObject() runtime.Object {
+	c := *d
+	d.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	return &c
+}
+
+type Engine string
+
+var tDatabase = reflect.TypeOf((*Database)(nil))
+
+var _ = Describe("Scheme", Ordered, func() {
+	var ctx context.Context
+
+	BeforeAll(func() {
+		Expect(stepdef.AddToScheme(func(s *runtime.Scheme) error {
+			s.AddKnownTypes(databaseGV, &Database{})
+			metav1.AddToGroupVersion(s, databaseGV)
+			return nil
+		})).Should(Succeed())
+
+		stepdef.RegisterParser(func(ctx context.Context, s string) (Engine, error) {
+			return Engine(strings.ToLower(s)), nil
+		})
+	})
+
+	BeforeEach(func() {
+		ctx = store.NewStoreFor(context.Background())
+
+		db := &unstructured.Unstructured{}
+		db.SetAPIVersion("example.com/v1")
+		db.SetKind("Database")
+		db.SetName("orders")
+		Expect(unstructured.SetNestedField(db.Object, "postgres", "spec", "engine")).Should(Succeed())
+		store.Save(ctx, "db", db)
+
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName("config")
+		store.Save(ctx, "cm", cm)
+	})
+
+	It("should convert a reference into a registered type", func() {
+		v, err := stepdef.ParseClientObject(ctx, "db", tDatabase)
+		Expect(err).ShouldNot(HaveOccurred())
+		db := v.Interface().(*Database)
+		Expect(db.Name).Should(Equal("orders"))
+		Expect(db.Spec.Engine).Should(Equal("postgres"))
+	})
+
+	It("should not convert a reference of another kind", func() {
+		_, err := stepdef.ParseClientObject(ctx, "cm", tDatabase)
+		Expect(err).Should(MatchError(ContainSubstring("expected a example.com/v1 Database but it is a v1 ConfigMap")))
+	})
+
+	It("should validate a manifest against the registered type", func() {
+		step := &messages.Step{DocString: &messages.DocString{Content: `apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: orders
+spec:
+  engine: postgres
+  replicas: 3`}}
+		_, err := stepdef.Manifest.Parse(ctx, step, tDatabase)
+		Expect(err).Should(MatchError(ContainSubstring(`unknown field "replicas"`)))
+
+		step.DocString.Content = strings.TrimSuffix(step.DocString.Content, "\n  replicas: 3")
+		v, err := stepdef.Manifest.Parse(ctx, step, tDatabase)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface().(*Database).Spec.Engine).Should(Equal("postgres"))
+	})
+
+	It("should parse registered types", func() {
+		v, err := stepdef.StringParsers.Parse(ctx, "Postgres", reflect.TypeOf(Engine("")))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v.Interface()).Should(Equal(Engine("postgres")))
+	})
+})
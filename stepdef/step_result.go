@@ -9,8 +9,8 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/testernetes/bdk/store"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -26,6 +26,16 @@ type StepResult struct {
 	Messages  []string `json:"messages,omitempty"`
 	Err       error    `json:"error,omitempty"`
 	Cleanup   []func() error
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is output captured by a step such as a command's stdout, a http
+// response body or an object's manifest.
+type Attachment struct {
+	Name      string `json:"name"`
+	MediaType string `json:"mime_type"`
+	Data      []byte `json:"data"`
 }
 
 type Result int
@@ -39,35 +49,84 @@ const (
 	Unknown
 )
 
+func (r Result) String() string {
+	switch r {
+	case Passed:
+		return "passed"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	case Interrupted:
+		return "interrupted"
+	case Timedout:
+		return "timedout"
+	}
+	return "unknown"
+}
+
 type T struct {
+	// Cluster is the name of the cluster the clients talk to
+	Cluster string
+	// Identity is the user the clients impersonate, it is nil if they use the
+	// identity of the kubeconfig
+	Identity *Identity
+	// Config is used to create clients, e.g. for streaming subresources
+	Config    *rest.Config
 	Client    client.WithWatch
 	Clientset kubernetes.Clientset
 	Log       logr.Logger
 
-	result StepResult
-	events StepEvents
-	step   *messages.Step
+	clients    *Clients
+	clientsErr error
+	result     StepResult
+	events     StepEvents
+	step       *messages.Step
 }
 
+// NewT returns the helper for a step. Its clients are those of the cluster from the
+// context, see ClusterFrom, impersonating the identity from the context, see IdentityFrom.
+// If there are no clients for the cluster, the T's clients are nil and ClientsErr says why.
 func NewT(ctx context.Context, sd StepDefinition, events StepEvents) *T {
-	var err error
+	step, _ := store.Load[*messages.Step](ctx, "step")
 	t := &T{
 		events: events,
-		step:   store.Load[*messages.Step](ctx, "step"),
+		step:   step,
 		result: StepResult{
 			StartTime: time.Now(),
 		},
 	}
 	t.Log = log.FromContext(ctx).WithName(sd.Name).V(1)
-	t.Clientset = *kubernetes.NewForConfigOrDie(config.GetConfigOrDie())
-	t.Client, err = client.NewWithWatch(config.GetConfigOrDie(), client.Options{})
+
+	t.Cluster = ClusterFrom(ctx)
+	clients, err := ClientsFor(ctx, t.Cluster)
 	if err != nil {
-		panic(err)
+		t.Log.Info("no clients", "cluster", t.Cluster, "error", err.Error())
+		t.clientsErr = fmt.Errorf("could not create clients for the %s cluster: %w", t.Cluster, err)
+		return t
 	}
+	if id, ok := IdentityFrom(ctx); ok {
+		clients, err = clients.Impersonate(id)
+		if err != nil {
+			t.Log.Info("cannot impersonate", "user", id.User, "error", err.Error())
+			t.clientsErr = fmt.Errorf("could not impersonate %s: %w", id, err)
+			return t
+		}
+		t.Identity = &id
+	}
+	t.clients = clients
+	t.Config = clients.Config
+	t.Client = clients.Client
+	t.Clientset = *clients.Clientset
 
 	return t
 }
 
+// ClientsErr is the reason the T has no clients
+func (t *T) ClientsErr() error {
+	return t.clientsErr
+}
+
 func (t *T) notify() {
 	if t.events == nil {
 		return
@@ -99,6 +158,16 @@ func (t *T) Cleanup(f func() error) {
 	t.notify()
 }
 
+// Attach adds output to the step's result so that it can be rendered by printers
+func (t *T) Attach(name, mediaType string, data []byte) {
+	t.result.Attachments = append(t.result.Attachments, Attachment{
+		Name:      name,
+		MediaType: mediaType,
+		Data:      data,
+	})
+	t.Log.Info("attached", "name", name, "mediaType", mediaType, "bytes", len(data))
+}
+
 func (t *T) SetProgress(percent float64) {
 	t.result.Progress = percent
 	t.Log.Info("progress set", "percent", percent)
@@ -0,0 +1,98 @@
+package stepdef
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+
+	messages "github.com/cucumber/messages/go/v21"
+	sprig "github.com/go-task/slim-sprig"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/yaml"
+)
+
+// TemplateSuffix marks a DocString media type as a Go template, e.g. yaml+tmpl
+const TemplateSuffix = "+tmpl"
+
+// IsTemplate returns true if the DocString should be rendered with RenderDocString
+func IsTemplate(ds *messages.DocString) bool {
+	return ds != nil && strings.HasSuffix(ds.MediaType, TemplateSuffix)
+}
+
+// RenderDocString renders a DocString whose media type ends in +tmpl with text/template
+// and returns a copy with the rendered content and the +tmpl suffix removed. Other
+// DocStrings are returned as they are.
+//
+// The data of the template is the scenario's variables, e.g. {{ .name }}. Stored
+// objects can be accessed with ref, e.g. {{ (ref "cm").metadata.uid }}.
+func RenderDocString(ctx context.Context, ds *messages.DocString) (*messages.DocString, error) {
+	if !IsTemplate(ds) {
+		return ds, nil
+	}
+
+	tmpl, err := template.New("docstring").
+		Option("missingkey=error").
+		Funcs(templateFuncs(ctx)).
+		Parse(ds.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]any{}
+	for key, value := range store.All(ctx) {
+		if name, ok := strings.CutPrefix(key, "scn-var-"); ok {
+			vars[name] = value
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := *ds
+	rendered.Content = buf.String()
+	rendered.MediaType = strings.TrimSuffix(ds.MediaType, TemplateSuffix)
+	return &rendered, nil
+}
+
+// templateFuncs are the sprig functions, see https://go-task.github.io/slim-sprig/, along
+// with functions to access the store and a few helm functions
+func templateFuncs(ctx context.Context) template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+
+	// store
+	funcs["ref"] = func(ref string) (any, error) {
+		v, err := store.Load[any](ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if u, ok := v.(*unstructured.Unstructured); ok {
+			return u.Object, nil
+		}
+		return v, nil
+	}
+	funcs["var"] = func(name string) (string, error) {
+		return store.Load[string](ctx, "scn-var-"+name)
+	}
+
+	// functions slim-sprig leaves out or which helm adds
+	funcs["randAlphaNum"] = RandChars
+	funcs["uuidv4"] = func() string { return string(uuid.NewUUID()) }
+	funcs["required"] = func(msg string, v any) (any, error) {
+		if s, ok := v.(string); v == nil || ok && s == "" {
+			return nil, errors.New(msg)
+		}
+		return v, nil
+	}
+	funcs["toYaml"] = func(v any) (string, error) {
+		b, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(b), "\n"), err
+	}
+	return funcs
+}
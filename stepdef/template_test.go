@@ -0,0 +1,96 @@
+package stepdef_test
+
+import (
+	"context"
+	"reflect"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("RenderDocString", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = store.NewStoreFor(context.Background())
+		store.Save(ctx, "scn-var-name", "example")
+
+		owner := &unstructured.Unstructured{}
+		owner.SetAPIVersion("v1")
+		owner.SetKind("ConfigMap")
+		owner.SetName("owner")
+		owner.SetUID("abc-123")
+		store.Save(ctx, "owner", owner)
+	})
+
+	It("should not render DocStrings which are not templates", func() {
+		ds := &messages.DocString{MediaType: "yaml", Content: "name: {{ .name }}"}
+		Expect(stepdef.RenderDocString(ctx, ds)).Should(BeIdenticalTo(ds))
+	})
+
+	It("should render variables, refs and functions", func() {
+		ds := &messages.DocString{MediaType: "yaml+tmpl", Content: `name: {{ .name }}
+uid: {{ (ref "owner").metadata.uid }}
+upper: {{ var "name" | upper }}
+items:
+{{- range $i := until 2 }}
+- {{ printf "%s-%d" $.name $i }}
+{{- end }}`}
+		rendered, err := stepdef.RenderDocString(ctx, ds)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rendered.MediaType).Should(Equal("yaml"))
+		Expect(rendered.Content).Should(Equal(`name: example
+uid: abc-123
+upper: EXAMPLE
+items:
+- example-0
+- example-1`))
+		Expect(ds.MediaType).Should(Equal("yaml+tmpl"), "should not modify the step's DocString")
+	})
+
+	It("should do arithmetic with numbers from refs and variables", func() {
+		deploy := &unstructured.Unstructured{}
+		deploy.SetAPIVersion("apps/v1")
+		deploy.SetKind("Deployment")
+		deploy.SetName("app")
+		Expect(unstructured.SetNestedField(deploy.Object, int64(2), "spec", "replicas")).Should(Succeed())
+		store.Save(ctx, "deploy", deploy)
+		store.Save(ctx, "scn-var-count", "3")
+
+		ds := &messages.DocString{MediaType: "yaml+tmpl", Content: `replicas: {{ add (ref "deploy").spec.replicas 1 }}
+count: {{ mul (var "count") 2 }}
+required: {{ required "name is required" .name }}`}
+		rendered, err := stepdef.RenderDocString(ctx, ds)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rendered.Content).Should(Equal("replicas: 3\ncount: 6\nrequired: example"))
+	})
+
+	It("should error on undefined references", func() {
+		ds := &messages.DocString{MediaType: "yaml+tmpl", Content: `{{ (ref "missing").metadata.uid }}`}
+		_, err := stepdef.RenderDocString(ctx, ds)
+		Expect(err).Should(MatchError(store.ErrNotFound))
+	})
+
+	It("should render a manifest before parsing it", func() {
+		step := &messages.Step{DocString: &messages.DocString{MediaType: "yaml+tmpl", Content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+  ownerReferences:
+  - apiVersion: v1
+    kind: ConfigMap
+    name: owner
+    uid: {{ (ref "owner").metadata.uid }}`}}
+		v, err := stepdef.Manifest.Parse(ctx, step, tUnstructured)
+		Expect(err).ShouldNot(HaveOccurred())
+		cm := v.Interface().(*unstructured.Unstructured)
+		Expect(cm.GetName()).Should(Equal("example"))
+		Expect(cm.GetOwnerReferences()).Should(HaveLen(1))
+		Expect(cm.GetOwnerReferences()[0].UID).Should(BeEquivalentTo("abc-123"))
+		Expect(reflect.TypeOf(cm)).Should(Equal(tUnstructured))
+	})
+})
@@ -11,21 +11,31 @@ import (
 var AResourceFromFile = stepdef.StepDefinition{
 	Name:     "a-resource-from-file",
 	Text:     "^a {reference} from {filename}$",
-	Function: SaveObjectFunc,
+	Function: SaveResourcesFunc,
 	StepArg:  stepdef.NoStepArg,
 	Help: `Assigns a reference to the resource given in the filename. This reference can be referred to
-in future steps in the same scenario. JSON and YAML formats are accepted.`,
+in future steps in the same scenario. JSON and YAML formats are accepted.
+
+If the file has multiple --- separated documents, or the filename is a directory or a glob of
+manifests, the reference is a resource set, even if it has one resource. Steps referring to a set act on each of its members
+and a member can be referred to individually as set/kind/name.`,
 	Examples: `
-	Given cm from config.yaml:`,
+	Given cm from config.yaml
+	And a app from manifests/
+	When I create app
+	Then within 1m app/deployment/example jsonpath '{.status.readyReplicas}' should be == 1`,
 }
 
 var AResource = stepdef.StepDefinition{
 	Name:     "a-resource",
 	Text:     "^a resource called {reference}$",
-	Function: SaveObjectFunc,
+	Function: SaveResourcesFunc,
 	StepArg:  stepdef.Manifest,
 	Help: `Assigns a reference to the resource given in the DocString. This reference can be referred to
-in future steps in the same scenario. JSON and YAML formats are accepted.`,
+in future steps in the same scenario. JSON and YAML formats are accepted.
+
+A DocString of multiple --- separated documents, or of a List, is saved as a resource set. Steps referring to
+a set act on each of its members and a member can be referred to individually as set/kind/name.`,
 	Examples: `Given a resource called cm:
 	  """
 	  apiVersion: v1
@@ -42,3 +52,12 @@ var SaveObjectFunc = func(ctx context.Context, ref string, u *unstructured.Unstr
 	store.Save(ctx, ref, u)
 	return nil
 }
+
+// SaveResourcesFunc saves the resource of a single document as an object and other resources,
+// e.g. of a directory even if it has one manifest, as a set
+var SaveResourcesFunc = func(ctx context.Context, ref string, resources stepdef.Resources) error {
+	if resources.Document {
+		return SaveObjectFunc(ctx, ref, resources.ResourceSet[0])
+	}
+	return stepdef.SaveResourceSet(ctx, ref, resources.ResourceSet)
+}
@@ -0,0 +1,193 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onsi/gomega/types"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const admissionHelp = `The API status of a rejection, its reason, message and causes, and the warnings of the
+response are recorded and can be asserted with the {reference} admission step.`
+
+var ICreateRejected = stepdef.StepDefinition{
+	Name: "i-create-rejected",
+	Text: "^I create {reference} it should be rejected$",
+	Help: `Creates the referenced resource and asserts that the API server rejects it, e.g. by an
+admission webhook, a ValidatingAdmissionPolicy or schema validation. ` + admissionHelp,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: Invalid_Name
+	    namespace: default
+	  """
+	When I create cm it should be rejected
+	Then cm admission '{.reason}' should equal Invalid`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, opts []client.CreateOption) error {
+		_, err := iCreateRejectedFunc(ctx, t, ref, opts)
+		return err
+	},
+}
+
+var ICreateRejectedWithMessage = stepdef.StepDefinition{
+	Name: "i-create-rejected-with-message",
+	Text: "^I create {reference} it should be rejected with message {text}$",
+	Help: `Creates the referenced resource and asserts that the API server rejects it with a message
+containing the text. ` + admissionHelp,
+	Examples: `
+	When I create cm it should be rejected with message "data.replicas: Invalid value"`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, message string, opts []client.CreateOption) error {
+		admission, err := iCreateRejectedFunc(ctx, t, ref, opts)
+		if err != nil {
+			return err
+		}
+		message = unquote(message)
+		if !strings.Contains(admission.Message, message) {
+			return fmt.Errorf("expected %s to be rejected with message %q but it was rejected with %q", ref, message, admission.Message)
+		}
+		return nil
+	},
+}
+
+var ICreateAllowed = stepdef.StepDefinition{
+	Name: "i-create-allowed",
+	Text: "^I create {reference} it should be allowed$",
+	Help: `Creates the referenced resource and asserts that the API server allows it. ` + admissionHelp + `
+The resource is deleted once the scenario has finished.`,
+	Examples: `
+	When I create cm it should be allowed
+	Then cm admission '{.warnings}' should be empty`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, opts []client.CreateOption) error {
+		_, err := iCreateAllowedFunc(ctx, t, ref, opts)
+		return err
+	},
+}
+
+var ICreateAllowedWithWarning = stepdef.StepDefinition{
+	Name: "i-create-allowed-with-warning",
+	Text: "^I create {reference} it should be allowed with warning {text}$",
+	Help: `Creates the referenced resource and asserts that the API server allows it with a warning
+containing the text. ` + admissionHelp + `
+The resource is deleted once the scenario has finished.`,
+	Examples: `
+	When I create cm it should be allowed with warning "deprecated"`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, warning string, opts []client.CreateOption) error {
+		admission, err := iCreateAllowedFunc(ctx, t, ref, opts)
+		if err != nil {
+			return err
+		}
+		warning = unquote(warning)
+		for _, w := range admission.Warnings {
+			if strings.Contains(w, warning) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected %s to be allowed with warning %q but the warnings were %q", ref, warning, admission.Warnings)
+	},
+}
+
+var AdmissionShould = stepdef.StepDefinition{
+	Name: "admission-should",
+	Text: "^{reference} admission {jsonpath} {should|should not} {matcher}$",
+	Help: `Asserts the recorded admission of the referenced resource, which has the fields allowed,
+code, reason, message, causes and warnings.`,
+	Examples: `
+	When I create cm it should be rejected
+	Then cm admission '{.code}' should be == 422
+	And cm admission '{.causes[*].field}' should contains data
+	And cm admission '{.message}' should not contains webhook`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, ref string, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) error {
+		admission, err := store.Load[*stepdef.Admission](ctx, "admission-"+ref)
+		if err != nil {
+			return fmt.Errorf("no admission was recorded for %s: %w", ref, err)
+		}
+		actual, err := admission.Object()
+		if err != nil {
+			return err
+		}
+
+		return assertJSONPath(actual, jsonpath, desiredMatch, matcher)
+	},
+}
+
+func iCreateRejectedFunc(ctx context.Context, t *stepdef.T, ref string, opts []client.CreateOption) (*stepdef.Admission, error) {
+	admission, err := iCreateAdmissionFunc(ctx, t, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	if admission.Allowed {
+		return nil, fmt.Errorf("expected %s to be rejected but it was allowed", ref)
+	}
+	return admission, nil
+}
+
+func iCreateAllowedFunc(ctx context.Context, t *stepdef.T, ref string, opts []client.CreateOption) (*stepdef.Admission, error) {
+	admission, err := iCreateAdmissionFunc(ctx, t, ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !admission.Allowed {
+		return nil, fmt.Errorf("expected %s to be allowed but it was %s", ref, admission)
+	}
+	return admission, nil
+}
+
+// iCreateAdmissionFunc creates the referenced resource and records whether it was
+// admitted, with the warnings of the response, as the admission of the reference
+func iCreateAdmissionFunc(ctx context.Context, t *stepdef.T, ref string, opts []client.CreateOption) (*stepdef.Admission, error) {
+	warnings := &stepdef.Warnings{}
+	c, err := t.WithWarnings(warnings)
+	if err != nil {
+		return nil, err
+	}
+	return createAdmission(ctx, t, c, warnings, ref, opts)
+}
+
+// createAdmission sends a single create request, only connection errors are retried so
+// that the response of a failing or denying webhook is recorded rather than retried
+func createAdmission(ctx context.Context, t *stepdef.T, c client.Client, warnings *stepdef.Warnings, ref string, opts []client.CreateOption) (*stepdef.Admission, error) {
+	obj, err := store.Load[*unstructured.Unstructured](ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.WithRetry(ctx, func() error {
+		return c.Create(ctx, obj, opts...)
+	}, stepdef.RetryConnectionError)
+	if err == nil && !isDryRun(opts) {
+		t.Cleanup(deleteFunc(ctx, t, obj))
+	}
+
+	admission, err := stepdef.NewAdmission(err, warnings.Messages())
+	if err != nil {
+		return nil, err
+	}
+	store.Save(ctx, "admission-"+ref, admission)
+	if b, err := json.MarshalIndent(admission, "", "  "); err == nil {
+		t.Attach("admission", "application/json", b)
+	}
+	return admission, nil
+}
+
+// unquote removes the double quotes around text, if it has them
+func unquote(text string) string {
+	if s, err := strconv.Unquote(text); err == nil {
+		return s
+	}
+	return text
+}
@@ -0,0 +1,76 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Admission", func() {
+	var (
+		ctx     context.Context
+		creates int
+	)
+
+	// create records the admission of creating a ConfigMap with a client whose creates
+	// return the errors in turn
+	create := func(errs ...error) (*stepdef.Admission, error) {
+		creates = 0
+		c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(stepdef.Scheme).Build(), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				creates++
+				if creates <= len(errs) {
+					return errs[creates-1]
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetNamespace("default")
+		cm.SetName("example")
+		store.Save(ctx, "cm", cm)
+
+		return createAdmission(ctx, &stepdef.T{Client: c}, c, &stepdef.Warnings{}, "cm", nil)
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(store.NewStoreFor(context.Background()), 5*time.Second)
+		DeferCleanup(cancel)
+	})
+
+	It("should record the response of a failing webhook without retrying", func() {
+		admission, err := create(k8sErrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com"`)))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(creates).Should(Equal(1))
+		Expect(admission.Allowed).Should(BeFalse())
+		Expect(admission.Reason).Should(Equal(metav1.StatusReasonInternalError))
+		Expect(admission.Message).Should(ContainSubstring("failed calling webhook"))
+		Expect(store.Load[*stepdef.Admission](ctx, "admission-cm")).Should(BeIdenticalTo(admission))
+	})
+
+	It("should retry when the API server cannot be reached", func() {
+		refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+		admission, err := create(refused)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(creates).Should(Equal(2))
+		Expect(admission.Allowed).Should(BeTrue())
+	})
+})
@@ -0,0 +1,115 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testernetes/bdk/stepdef"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteFunc returns a cleanup which deletes the object according to the
+// CleanupPolicy in the context. It deletes with the identity of the kubeconfig as
+// an impersonated user may not be allowed to delete what it created.
+func deleteFunc(ctx context.Context, t *stepdef.T, obj *unstructured.Unstructured) func() error {
+	c := t.Client
+	if clients, err := stepdef.ClientsFor(ctx, t.Cluster); err == nil {
+		c = clients.Client
+	}
+	return func() error {
+		policy := stepdef.CleanupPolicyFrom(ctx)
+
+		// cleanups run after the scenario, the step context may already be cancelled
+		ctx := context.WithoutCancel(ctx)
+
+		var opts []client.DeleteOption
+		propagation := policy.PropagationPolicy
+		if propagation == "" && policy.Wait {
+			propagation = metav1.DeletePropagationForeground
+		}
+		if propagation != "" {
+			opts = append(opts, client.PropagationPolicy(propagation))
+		}
+
+		if !policy.Wait {
+			return client.IgnoreNotFound(c.Delete(ctx, obj, opts...))
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+
+		return waitForDeletion(ctx, c, obj, func() error {
+			return c.Delete(ctx, obj, opts...)
+		})
+	}
+}
+
+// waitForDeletion runs del and then watches obj until it has been removed. If the
+// context expires first the object is reported as stuck along with any remaining
+// finalizers.
+func waitForDeletion(ctx context.Context, c client.WithWatch, obj *unstructured.Unstructured, del func() error) error {
+	list := &unstructured.Unstructured{}
+	list.SetGroupVersionKind(obj.GroupVersionKind())
+
+	// watch before deleting so that the deleted event cannot be missed
+	w, err := c.Watch(ctx, list,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", obj.GetName())},
+	)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	err = del()
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	last := obj.DeepCopy()
+	err = c.Get(ctx, client.ObjectKeyFromObject(obj), last)
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return stuckTerminatingError(last, ctx.Err())
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return stuckTerminatingError(last, errors.New("watch closed"))
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return nil
+			case watch.Modified, watch.Added:
+				if u, ok := event.Object.(*unstructured.Unstructured); ok {
+					last = u
+				}
+			}
+		}
+	}
+}
+
+func stuckTerminatingError(obj *unstructured.Unstructured, cause error) error {
+	id := fmt.Sprintf("%s %s", obj.GetKind(), client.ObjectKeyFromObject(obj))
+	if obj.GetDeletionTimestamp() == nil {
+		return fmt.Errorf("%s was not deleted: %w", id, cause)
+	}
+	return fmt.Errorf("%s is stuck terminating since %s with finalizers %q: %w",
+		id, obj.GetDeletionTimestamp().Format(time.RFC3339), obj.GetFinalizers(), cause)
+}
@@ -0,0 +1,87 @@
+package steps
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Cleanup", func() {
+	var (
+		ctx         context.Context
+		c           client.WithWatch
+		cm          *unstructured.Unstructured
+		propagation []metav1.DeletionPropagation
+	)
+
+	// cleanup deletes the ConfigMap with the policy like the cleanup of a created resource
+	cleanup := func(policy stepdef.CleanupPolicy) error {
+		ctx := stepdef.WithCleanupPolicy(ctx, policy)
+		return deleteFunc(ctx, &stepdef.T{Client: c}, cm)()
+	}
+
+	newConfigMap := func(finalizers ...string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace("default")
+		obj.SetName("example")
+		obj.SetFinalizers(finalizers)
+		return obj
+	}
+
+	build := func(obj *unstructured.Unstructured) {
+		cm = obj
+		propagation = nil
+		c = interceptor.NewClient(fake.NewClientBuilder().WithScheme(stepdef.Scheme).WithObjects(obj.DeepCopy()).Build(), interceptor.Funcs{
+			Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				var policy metav1.DeletionPropagation
+				if p := (&client.DeleteOptions{}).ApplyOptions(opts).PropagationPolicy; p != nil {
+					policy = *p
+				}
+				propagation = append(propagation, policy)
+				return c.Delete(ctx, obj, opts...)
+			},
+		})
+		ctx = stepdef.WithClients(context.Background(), &stepdef.Clients{Client: c})
+	}
+
+	It("should wait until the object is deleted", func() {
+		build(newConfigMap())
+		Expect(cleanup(stepdef.CleanupPolicy{Wait: true, Timeout: 5 * time.Second})).Should(Succeed())
+		err := c.Get(ctx, client.ObjectKeyFromObject(cm), newConfigMap())
+		Expect(k8sErrors.IsNotFound(err)).Should(BeTrue())
+	})
+
+	It("should report the finalizers of objects stuck terminating", func() {
+		build(newConfigMap("example.com/protect"))
+		err := cleanup(stepdef.CleanupPolicy{Wait: true, Timeout: 100 * time.Millisecond})
+		Expect(err).Should(MatchError(ContainSubstring(`ConfigMap default/example is stuck terminating since`)))
+		Expect(err).Should(MatchError(ContainSubstring(`with finalizers ["example.com/protect"]`)))
+		Expect(err).Should(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should delete in the foreground only when waiting", func() {
+		build(newConfigMap())
+		Expect(cleanup(stepdef.CleanupPolicy{Wait: true, Timeout: 5 * time.Second})).Should(Succeed())
+		Expect(propagation).Should(Equal([]metav1.DeletionPropagation{metav1.DeletePropagationForeground}))
+
+		build(newConfigMap())
+		Expect(cleanup(stepdef.CleanupPolicy{})).Should(Succeed())
+		Expect(propagation).Should(HaveLen(1))
+		Expect(propagation[0]).Should(BeEmpty())
+
+		build(newConfigMap())
+		Expect(cleanup(stepdef.CleanupPolicy{Wait: true, Timeout: 5 * time.Second, PropagationPolicy: metav1.DeletePropagationOrphan})).Should(Succeed())
+		Expect(propagation).Should(Equal([]metav1.DeletionPropagation{metav1.DeletePropagationOrphan}))
+	})
+})
@@ -0,0 +1,52 @@
+package steps
+
+import (
+	"context"
+
+	"github.com/testernetes/bdk/stepdef"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var ACluster = stepdef.StepDefinition{
+	Name: "a-cluster",
+	Text: "^cluster {cluster}$",
+	Help: `Switches the cluster of later steps in the scenario. Steps which refer to a resource
+use the cluster the resource was created in.`,
+	Examples: `
+	Given cluster east
+	And a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  """
+	When I create cm
+	Then cm should exist`,
+	StepArg:  stepdef.NoStepArg,
+	Function: stepdef.SetActiveCluster,
+}
+
+var ICreateInCluster = stepdef.StepDefinition{
+	Name: "i-create-in-cluster",
+	Text: "^I create {reference} in cluster {cluster}$",
+	Help: `Creates the referenced resource in the named cluster. Later steps which refer to the
+resource, and its cleanup, use the same cluster.`,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  """
+	When I create cm in cluster east
+	Then cm should exist`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, reference *unstructured.Unstructured, cluster string, opts []client.CreateOption) error {
+		return iCreateFunc(ctx, t, reference, opts)
+	},
+}
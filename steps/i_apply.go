@@ -0,0 +1,200 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+var IApply = stepdef.StepDefinition{
+	Name: "i-apply",
+	Text: "^I apply {reference}$",
+	Help: `Server-side applies the referenced manifest with the bdk field manager, unless a FieldOwner
+option is given. The reference stays the applied configuration, fields set by the API server or
+other managers are not claimed by later applies of it. Conflicts with other field managers fail
+the step and list the conflicting fields, the Force option takes ownership of them instead.
+The resource is deleted once the scenario has finished, see the --cleanup-* flags of bdk test.`,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  data:
+	    foo: bar
+	  """
+	When I apply cm
+	  | FieldOwner | my-controller |
+	Then cm field '{.data.foo}' should be owned by my-controller`,
+	StepArg: stepdef.PatchOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, opts []client.PatchOption) error {
+		return iApplyFunc(ctx, t, ref, stepdef.DefaultFieldManager, opts)
+	},
+}
+
+var IApplyAs = stepdef.StepDefinition{
+	Name: "i-apply-as",
+	Text: "^I apply {reference} as {text}$",
+	Help: `Server-side applies the referenced manifest as the named field manager. Used to test how
+multiple managers share ownership of a resource. Conflicts fail the step unless the Force
+option is given.`,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  data:
+	    foo: bar
+	  """
+	And I apply cm as alice
+	And I apply cm as bob
+	Then cm field '{.data.foo}' should be owned by alice
+	And cm field '{.data.foo}' should be owned by bob`,
+	StepArg: stepdef.PatchOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, manager string, opts []client.PatchOption) error {
+		return iApplyFunc(ctx, t, ref, manager, opts)
+	},
+}
+
+var FieldShouldBeOwnedBy = stepdef.StepDefinition{
+	Name: "field-should-be-owned-by",
+	Text: "^{reference} field {jsonpath} {should|should not} be owned by {text}$",
+	Help: `Asserts whether a field manager owns a field of the referenced resource according to its
+managedFields. The jsonpath must be field names such as '{.spec.replicas}'.`,
+	Examples: `
+	When I apply cm as alice
+	Then cm field '{.data.foo}' should be owned by alice
+	And cm field '{.data.foo}' should not be owned by bob`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, jsonpath string, owned bool, manager string) error {
+		path, err := stepdef.FieldPath(jsonpath)
+		if err != nil {
+			return err
+		}
+		return assertFieldManager(ctx, t, ref, path, owned, manager)
+	},
+}
+
+var ShouldBeManagedBy = stepdef.StepDefinition{
+	Name: "should-be-managed-by",
+	Text: "^{reference} {should|should not} be managed by {text}$",
+	Help: `Asserts whether a field manager owns any field of the referenced resource according to
+its managedFields.`,
+	Examples: `
+	When I apply cm as alice
+	Then cm should be managed by alice
+	And cm should not be managed by kubectl`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, managed bool, manager string) error {
+		return assertFieldManager(ctx, t, ref, nil, managed, manager)
+	},
+}
+
+func iApplyFunc(ctx context.Context, t *stepdef.T, ref string, manager string, opts []client.PatchOption) error {
+	reference, err := store.Load[*unstructured.Unstructured](ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	// apply the configuration, not what the API server returned for an earlier request
+	config := reference.DeepCopy()
+	config.SetResourceVersion("")
+	config.SetUID("")
+	config.SetGeneration(0)
+	config.SetCreationTimestamp(metav1.Time{})
+	config.SetManagedFields(nil)
+	unstructured.RemoveNestedField(config.Object, "status")
+
+	if b, err := yaml.Marshal(config.Object); err == nil {
+		t.Attach("applied configuration ("+manager+")", "application/yaml", b)
+	}
+
+	obj := config.DeepCopy()
+	opts = append([]client.PatchOption{client.FieldOwner(manager)}, opts...)
+	err = t.WithRetry(ctx, func() error {
+		return t.Client.Patch(ctx, obj, client.Apply, opts...)
+	}, retryUnlessConflict)
+	if k8sErrors.IsConflict(err) {
+		return applyConflictError(ref, manager, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the resource is deleted once however often it is applied
+	if _, err := store.Load[bool](ctx, "apply-cleanup-"+ref); err != nil {
+		store.Save(ctx, "apply-cleanup-"+ref, true)
+		t.Cleanup(deleteFunc(ctx, t, obj))
+	}
+	return nil
+}
+
+// retryUnlessConflict retries like stepdef.RetryK8sError except for conflicts, a
+// conflicting apply will conflict again
+func retryUnlessConflict(err error) (bool, time.Duration) {
+	if k8sErrors.IsConflict(err) {
+		return false, 0
+	}
+	return stepdef.RetryK8sError(err)
+}
+
+// applyConflictError lists the fields of an apply which are owned by other managers
+func applyConflictError(ref, manager string, err error) error {
+	var conflicts []string
+	var apiStatus k8sErrors.APIStatus
+	if errors.As(err, &apiStatus) && apiStatus.Status().Details != nil {
+		for _, cause := range apiStatus.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				conflicts = append(conflicts, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return fmt.Errorf("applying %s as %s conflicts with other field managers: %w", ref, manager, err)
+	}
+	return fmt.Errorf("applying %s as %s conflicts with other field managers, use the Force option to take ownership:\n  %s",
+		ref, manager, strings.Join(conflicts, "\n  "))
+}
+
+func assertFieldManager(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, path []string, owned bool, manager string) error {
+	obj := ref.DeepCopy()
+	err := t.WithRetry(ctx, func() error {
+		return t.Client.Get(ctx, client.ObjectKeyFromObject(ref), obj)
+	}, stepdef.RetryK8sError)
+	if err != nil {
+		return err
+	}
+
+	managers, err := stepdef.FieldManagers(obj, path)
+	if err != nil {
+		return err
+	}
+
+	what := ref.GetName()
+	if len(path) > 0 {
+		what = fmt.Sprintf(".%s of %s", strings.Join(path, "."), ref.GetName())
+	}
+	if owned && !slices.Contains(managers, manager) {
+		return fmt.Errorf("expected %s to be owned by %s but it is owned by %q", what, manager, managers)
+	}
+	if !owned && slices.Contains(managers, manager) {
+		return fmt.Errorf("expected %s not to be owned by %s but it is owned by %q", what, manager, managers)
+	}
+	return nil
+}
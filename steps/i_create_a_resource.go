@@ -2,8 +2,10 @@ package steps
 
 import (
 	"context"
+	"errors"
 
 	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -11,7 +13,8 @@ import (
 var ICreate = stepdef.StepDefinition{
 	Name: "i-create",
 	Text: "^I create {reference}$",
-	Help: `Creates the referenced resource. Step will fail if the reference was not defined in a previous step.`,
+	Help: `Creates the referenced resource. Step will fail if the reference was not defined in a previous step.
+The resource is deleted once the scenario has finished, see the --cleanup-* flags of bdk test.`,
 	Examples: `
 	Given a resource called cm:
 	  """
@@ -38,8 +41,68 @@ var iCreateFunc = func(ctx context.Context, t *stepdef.T, reference *unstructure
 	if err != nil {
 		return err
 	}
-	t.Cleanup(func() error {
-		return t.Client.Delete(ctx, reference)
-	})
+	if !isDryRun(opts) {
+		t.Cleanup(deleteFunc(ctx, t, reference))
+	}
 	return
 }
+
+// isDryRun returns true if the options make a create a dry run which persists nothing
+func isDryRun(opts []client.CreateOption) bool {
+	return len((&client.CreateOptions{}).ApplyOptions(opts).DryRun) > 0
+}
+
+var ICreateOncePer = stepdef.StepDefinition{
+	Name: "i-create-once-per",
+	Text: "^I create {reference} once per {scope}$",
+	Help: `Creates the referenced resource the first time the step runs in the scope, later scenarios
+in the scope refer to the same resource. The resource is deleted once the scope ends.`,
+	Examples: `
+	Background:
+	  Given a resource called operator:
+	    """
+	    apiVersion: v1
+	    kind: ConfigMap
+	    metadata:
+	      name: operator-config
+	      namespace: default
+	    """
+	  And I create operator once per feature`,
+	StepArg:  stepdef.CreateOptions,
+	Function: iCreateOncePerFunc,
+}
+
+var iCreateOncePerFunc = func(ctx context.Context, t *stepdef.T, ref string, scope store.Scope, opts []client.CreateOption) (err error) {
+	key := "created-once-" + ref
+	unlock, err := store.Lock(ctx, scope, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	created, err := store.Load[*unstructured.Unstructured](ctx, key)
+	if err == nil {
+		store.Save(ctx, ref, created)
+		return nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	reference, err := store.Load[*unstructured.Unstructured](ctx, ref)
+	if err != nil {
+		return err
+	}
+	err = t.WithRetry(ctx, func() error {
+		return t.Client.Create(ctx, reference, opts...)
+	}, stepdef.RetryK8sError)
+	if err != nil {
+		return err
+	}
+
+	err = store.SaveTo(ctx, scope, key, reference)
+	if err != nil {
+		return err
+	}
+	return store.Cleanup(ctx, scope, deleteFunc(ctx, t, reference))
+}
@@ -0,0 +1,48 @@
+package steps
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Creating once per scope", func() {
+	It("should create once when features run in parallel", func() {
+		var creates atomic.Int32
+		c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(stepdef.Scheme).Build(), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				creates.Add(1)
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+		suite := store.NewScope(stepdef.WithClients(context.Background(), &stepdef.Clients{Client: c}), store.SuiteScope)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				ctx := store.NewStoreFor(store.NewScope(suite, store.FeatureScope))
+				cm := &unstructured.Unstructured{}
+				cm.SetAPIVersion("v1")
+				cm.SetKind("ConfigMap")
+				cm.SetNamespace("default")
+				cm.SetName("operator")
+				store.Save(ctx, "operator", cm)
+				Expect(iCreateOncePerFunc(ctx, &stepdef.T{Client: c}, "operator", store.SuiteScope, nil)).Should(Succeed())
+			}()
+		}
+		wg.Wait()
+		Expect(creates.Load()).Should(BeEquivalentTo(1))
+	})
+})
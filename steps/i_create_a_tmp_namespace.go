@@ -2,9 +2,11 @@ package steps
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -48,3 +50,53 @@ var ICreateATmpNamespace = stepdef.StepDefinition{
 		return iCreateFunc(ctx, t, u, nil)
 	},
 }
+
+var ICreateATmpNamespaceOncePer = stepdef.StepDefinition{
+	Name: "i-create-a-tmp-namespace-once-per",
+	Text: "^I create a temporary namespace called {var} once per {scope}$",
+	Help: `Creates a namespace of the same name as reference with some random characters the first time
+the step runs in the scope. The variable is set in the scope and the namespace is deleted once the scope ends.`,
+	Examples: `
+	Background:
+	  Given I create a temporary namespace called ns once per feature`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, name string, scope store.Scope) (err error) {
+		key := "created-once-ns-" + name
+		unlock, err := store.Lock(ctx, scope, key)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		_, err = store.Load[*unstructured.Unstructured](ctx, key)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+
+		randName := fmt.Sprintf("%s-%s", name, stepdef.RandChars(5))
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("Namespace")
+		u.SetName(randName)
+
+		err = t.WithRetry(ctx, func() error {
+			return t.Client.Create(ctx, u)
+		}, stepdef.RetryK8sError)
+		if err != nil {
+			return err
+		}
+
+		err = SetScopedVarFunc(ctx, scope, name, randName)
+		if err != nil {
+			return err
+		}
+		err = store.SaveTo(ctx, scope, key, u)
+		if err != nil {
+			return err
+		}
+		return store.Cleanup(ctx, scope, deleteFunc(ctx, t, u))
+	},
+}
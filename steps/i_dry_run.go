@@ -0,0 +1,153 @@
+package steps
+
+import (
+	"context"
+
+	"github.com/onsi/gomega/types"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const dryRunHelp = `The request is sent with server-side dry run so nothing is persisted, and the object returned
+by the API server, after defaulting and mutating admission, is saved as a new reference. Jsonpath
+assertions on the new reference assert the returned object as nothing can be watched.`
+
+var IDryRunCreate = stepdef.StepDefinition{
+	Name: "i-dry-run-create",
+	Text: "^I dry-run create {reference} as {reference}$",
+	Help: `Creates the referenced resource with server-side dry run. ` + dryRunHelp,
+	Examples: `
+	Given a resource called deploy:
+	  """
+	  apiVersion: apps/v1
+	  kind: Deployment
+	  metadata:
+	    name: example
+	    namespace: default
+	  spec:
+	    selector:
+	      matchLabels:
+	        app: example
+	    template:
+	      metadata:
+	        labels:
+	          app: example
+	      spec:
+	        containers:
+	        - name: app
+	          image: nginx
+	  """
+	When I dry-run create deploy as defaulted
+	Then defaulted jsonpath '{.spec.replicas}' should be == 1
+	And defaulted jsonpath '{.spec.strategy.type}' should equal RollingUpdate`,
+	StepArg: stepdef.CreateOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, result string, opts []client.CreateOption) error {
+		obj := ref.DeepCopy()
+		obj.SetResourceVersion("")
+		return dryRun(ctx, t, obj, result, func() error {
+			return t.Client.Create(ctx, obj, append(opts, client.DryRunAll)...)
+		})
+	},
+}
+
+var IDryRunUpdate = stepdef.StepDefinition{
+	Name: "i-dry-run-update",
+	Text: "^I dry-run update {reference} as {reference}$",
+	Help: `Updates the resource with the referenced manifest with server-side dry run. The update is
+unconditional, the resourceVersion of the reference is ignored. ` + dryRunHelp,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  data:
+	    foo: bar
+	  """
+	And I create cm
+	When I dry-run update cm as updated
+	Then updated jsonpath '{.metadata.labels.mutated}' should equal true`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, result string) error {
+		obj := ref.DeepCopy()
+		obj.SetResourceVersion("")
+		return dryRun(ctx, t, obj, result, func() error {
+			return t.Client.Update(ctx, obj, client.DryRunAll)
+		})
+	},
+}
+
+var IDryRunPatch = stepdef.StepDefinition{
+	Name: "i-dry-run-patch",
+	Text: "^I dry-run patch {reference} with {reference} as {reference}$",
+	Help: `Patches the referenced resource with server-side dry run. ` + dryRunHelp,
+	Examples: `
+	Given a patch called mypatch
+	  """application/merge-patch+json
+	  {"data":{"foo":"nobar"}}
+	  """
+	When I dry-run patch cm with mypatch as patched
+	Then patched jsonpath '{.data.foo}' should equal nobar
+	And cm jsonpath '{.data.foo}' should equal bar`,
+	StepArg: stepdef.PatchOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, patch client.Patch, result string, opts []client.PatchOption) error {
+		obj := ref.DeepCopy()
+		return dryRun(ctx, t, obj, result, func() error {
+			return t.Client.Patch(ctx, obj, patch, append(opts, client.DryRunAll)...)
+		})
+	},
+}
+
+var IDryRunApply = stepdef.StepDefinition{
+	Name: "i-dry-run-apply",
+	Text: "^I dry-run apply {reference} as {reference}$",
+	Help: `Server-side applies the referenced manifest with dry run. The field manager is bdk unless
+a FieldOwner option is given. ` + dryRunHelp,
+	Examples: `
+	When I dry-run apply cm as applied
+	  | FieldOwner | my-controller |
+	  | Force      | true          |
+	Then applied jsonpath '{.metadata.managedFields[*].manager}' should contains my-controller`,
+	StepArg: stepdef.PatchOptions,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, result string, opts []client.PatchOption) error {
+		obj := ref.DeepCopy()
+		obj.SetResourceVersion("")
+		obj.SetManagedFields(nil)
+		opts = append([]client.PatchOption{client.FieldOwner(stepdef.DefaultFieldManager)}, opts...)
+		return dryRun(ctx, t, obj, result, func() error {
+			return t.Client.Patch(ctx, obj, client.Apply, append(opts, client.DryRunAll)...)
+		})
+	},
+}
+
+// dryRun sends the dry run request and saves the object returned by the API server as
+// result. No cleanup is registered as nothing was persisted.
+func dryRun(ctx context.Context, t *stepdef.T, obj *unstructured.Unstructured, result string, request func() error) error {
+	err := t.WithRetry(ctx, request, stepdef.RetryK8sError)
+	if err != nil {
+		return err
+	}
+	store.Save(ctx, result, obj)
+	store.Save(ctx, "dry-run-"+result, obj)
+	return nil
+}
+
+// dryRunResult returns the object saved as ref by a dry run step, if it is one
+func dryRunResult(ctx context.Context, ref string) (*unstructured.Unstructured, bool) {
+	obj, err := store.Load[*unstructured.Unstructured](ctx, "dry-run-"+ref)
+	if err != nil {
+		return nil, false
+	}
+	current, err := store.Load[*unstructured.Unstructured](ctx, ref)
+	return obj, err == nil && current == obj
+}
+
+// assertJSONPath asserts a value which cannot change, such as the object returned by a dry run, once
+func assertJSONPath(actual any, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) error {
+	_, err := stepdef.Eventually(desiredMatch, stepdef.NewHaveJSONPathMatcher(jsonpath, matcher), actual)
+	return err
+}
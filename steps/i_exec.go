@@ -14,7 +14,6 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	k8sExec "k8s.io/utils/exec"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 type PodSession struct {
@@ -40,7 +39,7 @@ var IExecFunc = func(ctx context.Context, t *stepdef.T, cmd []string, pod *corev
 		SubResource("exec").
 		VersionedParams(podExecOpts, scheme.ParameterCodec)
 
-	executor, err := remotecommand.NewSPDYExecutor(config.GetConfigOrDie(), http.MethodPost, execReq.URL())
+	executor, err := remotecommand.NewSPDYExecutor(t.Config, http.MethodPost, execReq.URL())
 	if err != nil {
 		return err
 	}
@@ -64,6 +63,8 @@ var IExecFunc = func(ctx context.Context, t *stepdef.T, cmd []string, pod *corev
 		}
 	}
 	store.Save(ctx, client.ObjectKeyFromObject(pod).String(), session)
+	t.Attach("stdout", "text/plain", session.Out.Contents())
+	t.Attach("stderr", "text/plain", session.Err.Contents())
 	return err
 }
 
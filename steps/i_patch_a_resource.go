@@ -71,8 +71,8 @@ var IPatch = stepdef.StepDefinition{
 	Then for at least 10s cm jsonpath '{.data.foo}' should equal nobar`,
 	StepArg: stepdef.PatchOptions,
 	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, patch client.Patch, opts []client.PatchOption) (err error) {
-		return withRetry(ctx, func() error {
+		return t.WithRetry(ctx, func() error {
 			return t.Client.Patch(ctx, ref, patch, opts...)
-		})
+		}, stepdef.RetryK8sError)
 	},
 }
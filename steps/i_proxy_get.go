@@ -37,6 +37,7 @@ var IProxyGetFunc = func(ctx context.Context, t *stepdef.T, scheme string, obj *
 	_, err = io.Copy(session.Out, stream)
 
 	store.Save(ctx, client.ObjectKeyFromObject(obj).String(), session)
+	t.Attach("response", "text/plain", session.Out.Contents())
 
 	return nil
 }
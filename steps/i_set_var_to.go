@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/testernetes/bdk/redact"
 	"github.com/testernetes/bdk/stepdef"
 	"github.com/testernetes/bdk/store"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -20,12 +21,36 @@ var ISetVar = stepdef.StepDefinition{
 	Help:     `Assigns a value to a variable`,
 }
 
+var ISetSecretVar = stepdef.StepDefinition{
+	Name:     "i-set-secret-var",
+	Text:     "^I set secret var {var} to {secret}$",
+	Function: SetSecretVarFunc,
+	StepArg:  stepdef.NoStepArg,
+	Help: `Assigns a sensitive value to a variable. The value is masked wherever it is printed
+or logged for the rest of the run.`,
+	Examples: `
+	Given I set secret var token to ${GITHUB_TOKEN}`,
+}
+
+var ISetScopedVar = stepdef.StepDefinition{
+	Name:     "i-set-scoped-var",
+	Text:     "^I set {scope} var {var} to {text}$",
+	Function: SetScopedVarFunc,
+	StepArg:  stepdef.NoStepArg,
+	Help: `Assigns a value to a variable which can be referred to by every scenario in the scope
+until the scope ends.`,
+	Examples: `
+	Given I set global var registry to ghcr.io/testernetes
+	And I set feature var image to ${registry}/bdk:latest`,
+}
+
 var ISetVarFromJSONPath = stepdef.StepDefinition{
 	Name:     "i-set-var",
 	Text:     "^I set {var} from {reference} jsonpath {jsonpath}$",
 	Function: SetVarFromJSONPathFunc,
 	StepArg:  stepdef.NoStepArg,
-	Help:     `Assigns a value to a variable`,
+	Help: `Assigns a value to a variable. Values read from a Secret are treated as sensitive and are
+masked wherever they are printed or logged.`,
 }
 
 func FromJSONPathFunc(o client.Object, jp string) ([][]reflect.Value, error) {
@@ -55,8 +80,13 @@ var SetVarFromJSONPathFunc = func(ctx context.Context, key string, ref *unstruct
 		return fmt.Errorf("jsonpath should resolve to a single variable")
 	}
 
+	value := fmt.Sprint(values[0][0].Interface())
+	if ref.GetKind() == "Secret" {
+		redact.AddEncoded(value)
+	}
+
 	key = "scn-var-" + key
-	store.Save(ctx, key, fmt.Sprint(values[0][0].Interface()))
+	store.Save(ctx, key, value)
 	return nil
 }
 
@@ -65,3 +95,14 @@ var SetVarFunc = func(ctx context.Context, key, value string) (err error) {
 	store.Save(ctx, key, value)
 	return nil
 }
+
+var SetSecretVarFunc = func(ctx context.Context, key, value string) (err error) {
+	// a value too short to be masked is warned about when the step is evaluated
+	_ = redact.Add(value)
+	return SetVarFunc(ctx, key, value)
+}
+
+var SetScopedVarFunc = func(ctx context.Context, scope store.Scope, key, value string) (err error) {
+	key = "scn-var-" + key
+	return store.SaveTo(ctx, scope, key, value)
+}
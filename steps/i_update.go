@@ -0,0 +1,155 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testernetes/bdk/stepdef"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var IUpdate = stepdef.StepDefinition{
+	Name: "i-update",
+	Text: "^I update {reference}$",
+	Help: `Updates the referenced resource with the fields of the table. The stored object is sent with
+its resourceVersion, if another actor changed the resource since, the latest version is read,
+the fields are set again and the update retried. Without a table a conflict fails the step.`,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  data:
+	    foo: bar
+	  """
+	And I create cm
+	When I update cm
+	  | .data.foo        | nobar        |
+	  | .metadata.labels | {app: hello} |
+	Then cm jsonpath '{.data.foo}' should equal nobar`,
+	StepArg: stepdef.Fields,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, mutation stepdef.Mutation) error {
+		return iUpdateFunc(ctx, t, ref, mutation, func(obj client.Object) error {
+			return t.Client.Update(ctx, obj)
+		})
+	},
+}
+
+var IUpdateStatus = stepdef.StepDefinition{
+	Name: "i-update-status",
+	Text: "^I update status of {reference}$",
+	Help: `Updates the status subresource of the referenced resource with the fields of the table,
+e.g. to act as the controller of the resource. Conflicts are retried like I update.`,
+	Examples: `
+	Given I create deploy
+	When I update status of deploy
+	  | .status.conditions | [{type: Available, status: "False", reason: Testing}] |
+	Then deploy jsonpath '{.status.conditions[0].reason}' should equal Testing`,
+	StepArg: stepdef.Fields,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, mutation stepdef.Mutation) error {
+		return iUpdateFunc(ctx, t, ref, mutation, func(obj client.Object) error {
+			return t.Client.Status().Update(ctx, obj)
+		})
+	},
+}
+
+var IReplace = stepdef.StepDefinition{
+	Name: "i-replace",
+	Text: "^I replace {reference} with$",
+	Help: `Replaces the referenced resource with the manifest, like kubectl replace. The name,
+namespace and kind default to those of the reference. Unless the manifest has a resourceVersion
+the latest version of the resource is replaced.`,
+	Examples: `
+	Given I create cm
+	When I replace cm with
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  data:
+	    replaced: "true"
+	  """
+	Then cm jsonpath '{.data.replaced}' should equal true
+	And cm jsonpath '{.data.foo}' should be empty`,
+	StepArg: stepdef.Replacement,
+	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, manifest *unstructured.Unstructured) error {
+		obj := manifest.DeepCopy()
+		if obj.GetName() == "" {
+			obj.SetName(ref.GetName())
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(ref.GetNamespace())
+		}
+		if obj.GetKind() == "" {
+			obj.SetGroupVersionKind(ref.GroupVersionKind())
+		}
+		conditional := obj.GetResourceVersion() != ""
+
+		err := t.WithRetry(ctx, func() error {
+			if !conditional {
+				latest := &unstructured.Unstructured{}
+				latest.SetGroupVersionKind(obj.GroupVersionKind())
+				if err := t.Client.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+					return err
+				}
+				obj.SetResourceVersion(latest.GetResourceVersion())
+			}
+			return t.Client.Update(ctx, obj)
+		}, func(err error) (bool, time.Duration) {
+			if conditional {
+				return retryUnlessConflict(err)
+			}
+			return stepdef.RetryK8sError(err)
+		})
+		if err != nil {
+			return err
+		}
+		// later steps refer to the replacement
+		ref.Object = obj.Object
+		return nil
+	},
+}
+
+// iUpdateFunc sets the fields of the mutation on a copy of the stored object and updates it.
+// On a conflict the latest version is read and the mutation applied to it again. The stored
+// object is only changed once the update succeeds.
+func iUpdateFunc(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, mutation stepdef.Mutation, update func(client.Object) error) error {
+	obj := ref.DeepCopy()
+	if err := mutation.Apply(obj); err != nil {
+		return err
+	}
+
+	retry := stepdef.RetryK8sError
+	if len(mutation) == 0 {
+		retry = retryUnlessConflict
+	}
+
+	err := t.WithRetry(ctx, func() error {
+		err := update(obj)
+		if !k8sErrors.IsConflict(err) || len(mutation) == 0 {
+			return err
+		}
+		t.Log.Info("conflict, setting the fields on the latest version", "resourceVersion", obj.GetResourceVersion())
+		if getErr := t.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); getErr != nil {
+			return getErr
+		}
+		if mutationErr := mutation.Apply(obj); mutationErr != nil {
+			return mutationErr
+		}
+		// the conflict is retried with the latest version
+		return err
+	}, retry)
+	if k8sErrors.IsConflict(err) && len(mutation) == 0 {
+		return fmt.Errorf("%s was changed since it was read, get it first or give the fields to update: %w", ref.GetName(), err)
+	}
+	if err != nil {
+		return err
+	}
+	ref.Object = obj.Object
+	return nil
+}
@@ -0,0 +1,108 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	messages "github.com/cucumber/messages/go/v21"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Updating", func() {
+	var (
+		ctx       context.Context
+		c         client.WithWatch
+		updates   int
+		updateErr error
+		cm        *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(store.NewStoreFor(context.Background()), 5*time.Second)
+		DeferCleanup(cancel)
+
+		updates, updateErr = 0, nil
+		c = interceptor.NewClient(fake.NewClientBuilder().WithScheme(stepdef.Scheme).Build(), interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updates++
+				if updateErr != nil {
+					return updateErr
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		})
+
+		cm = &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetNamespace("default")
+		cm.SetName("example")
+		Expect(unstructured.SetNestedField(cm.Object, "bar", "data", "foo")).Should(Succeed())
+		Expect(c.Create(ctx, cm)).Should(Succeed())
+	})
+
+	latest := func() *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), obj)).Should(Succeed())
+		return obj
+	}
+
+	update := func(mutation stepdef.Mutation) error {
+		f := IUpdate.Function.(func(context.Context, *stepdef.T, *unstructured.Unstructured, stepdef.Mutation) error)
+		return f(ctx, &stepdef.T{Client: c}, cm, mutation)
+	}
+
+	It("should set the fields again on the latest version after a conflict", func() {
+		changed := cm.DeepCopy()
+		changed.SetLabels(map[string]string{"changed": "elsewhere"})
+		Expect(c.Update(ctx, changed)).Should(Succeed())
+		updates = 0
+
+		Expect(update(stepdef.Mutation{{Path: []string{"data", "foo"}, Value: "nobar"}})).Should(Succeed())
+		Expect(updates).Should(Equal(2))
+
+		obj := latest()
+		Expect(obj.GetLabels()).Should(HaveKeyWithValue("changed", "elsewhere"))
+		Expect(obj.Object["data"]).Should(HaveKeyWithValue("foo", "nobar"))
+		Expect(cm.GetResourceVersion()).Should(Equal(obj.GetResourceVersion()))
+	})
+
+	It("should not change the stored object when the update fails", func() {
+		updateErr = k8sErrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "example", errors.New("denied"))
+
+		Expect(update(stepdef.Mutation{{Path: []string{"data", "foo"}, Value: "nobar"}})).Should(MatchError(updateErr))
+		Expect(cm.Object["data"]).Should(HaveKeyWithValue("foo", "bar"))
+	})
+
+	It("should replace a resource with a manifest without a name", func() {
+		step := &messages.Step{DocString: &messages.DocString{Content: `apiVersion: v1
+kind: ConfigMap
+data:
+  replaced: "true"
+`}}
+		manifest, err := stepdef.Replacement.Parse(ctx, step, reflect.TypeOf(&unstructured.Unstructured{}))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		f := IReplace.Function.(func(context.Context, *stepdef.T, *unstructured.Unstructured, *unstructured.Unstructured) error)
+		Expect(f(ctx, &stepdef.T{Client: c}, cm, manifest.Interface().(*unstructured.Unstructured))).Should(Succeed())
+
+		obj := latest()
+		Expect(obj.Object["data"]).Should(Equal(map[string]any{"replaced": "true"}))
+		Expect(cm.GetName()).Should(Equal("example"))
+		Expect(cm.Object["data"]).Should(Equal(map[string]any{"replaced": "true"}))
+	})
+})
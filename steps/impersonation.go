@@ -0,0 +1,154 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testernetes/bdk/stepdef"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var IAmUser = stepdef.StepDefinition{
+	Name: "i-am-user",
+	Text: "^I am user {user}$",
+	Help: `Later steps in the scenario impersonate the user instead of using the identity of the
+kubeconfig. A single step can impersonate a user by starting with "as user <name>", e.g.
+"When as user bob I delete cm". Resources are still cleaned up with the identity of the kubeconfig.`,
+	Examples: `
+	Given I am user alice
+	When I create cm
+	Then as user bob I should be forbidden from deleting cm`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, user string) error {
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{User: user})
+		return nil
+	},
+}
+
+var IAmUserInGroups = stepdef.StepDefinition{
+	Name: "i-am-user-in-groups",
+	Text: "^I am user {user} in groups {groups}$",
+	Help: `Later steps in the scenario impersonate the user and groups instead of using the
+identity of the kubeconfig. A single step can impersonate them by starting with
+"as user <name> in groups <groups>".`,
+	Examples: `
+	Given I am user alice in groups dev,qa
+	When I create cm`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, user string, groups []string) error {
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{User: user, Groups: groups})
+		return nil
+	},
+}
+
+var IAmServiceAccount = stepdef.StepDefinition{
+	Name: "i-am-serviceaccount",
+	Text: "^I am serviceaccount {serviceaccount}$",
+	Help: `Later steps in the scenario impersonate the service account instead of using the
+identity of the kubeconfig. A single step can impersonate a service account by starting with
+"as serviceaccount <namespace>/<name>".`,
+	Examples: `
+	Given I am serviceaccount default/builder
+	When I create cm
+	Then as serviceaccount default/viewer I should be forbidden from deleting cm`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, id stepdef.Identity) error {
+		stepdef.SetActiveIdentity(ctx, id)
+		return nil
+	},
+}
+
+var IStopImpersonating = stepdef.StepDefinition{
+	Name: "i-stop-impersonating",
+	Text: "^I stop impersonating$",
+	Help: `Later steps in the scenario use the identity of the kubeconfig again.`,
+	Examples: `
+	Given I am user alice
+	And I stop impersonating
+	When I create cm`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context) error {
+		stepdef.SetActiveIdentity(ctx, stepdef.Identity{})
+		return nil
+	},
+}
+
+var IShouldBeForbidden = stepdef.StepDefinition{
+	Name: "i-should-be-forbidden",
+	Text: "^I {should|should not} be forbidden from {action} {reference}$",
+	Help: `Asserts whether the API server forbids the action on the referenced resource, either by
+authorization or by admission. Creating, updating, patching and deleting are dry run so that
+nothing changes when the action is allowed. Usually combined with impersonation.`,
+	Examples: `
+	Given a resource called cm:
+	  """
+	  apiVersion: v1
+	  kind: ConfigMap
+	  metadata:
+	    name: example
+	    namespace: default
+	  """
+	And I create cm
+	Then as user bob I should be forbidden from deleting cm
+	And as user alice in groups admins I should not be forbidden from deleting cm`,
+	StepArg:  stepdef.NoStepArg,
+	Function: IShouldBeForbiddenFunc,
+}
+
+var IShouldBeForbiddenFunc = func(ctx context.Context, t *stepdef.T, forbidden bool, action string, ref *unstructured.Unstructured) error {
+	obj := ref.DeepCopy()
+
+	var do func() error
+	switch action {
+	case "creating":
+		obj.SetResourceVersion("")
+		do = func() error { return t.Client.Create(ctx, obj.DeepCopy(), client.DryRunAll) }
+	case "getting":
+		do = func() error { return t.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopy()) }
+	case "listing":
+		do = func() error {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(obj.GroupVersionKind())
+			return t.Client.List(ctx, list, client.InNamespace(obj.GetNamespace()))
+		}
+	case "updating":
+		do = func() error { return t.Client.Update(ctx, obj.DeepCopy(), client.DryRunAll) }
+	case "patching":
+		do = func() error {
+			return t.Client.Patch(ctx, obj.DeepCopy(), client.RawPatch(client.Merge.Type(), []byte("{}")), client.DryRunAll)
+		}
+	case "deleting":
+		do = func() error { return t.Client.Delete(ctx, obj.DeepCopy(), client.DryRunAll) }
+	default:
+		return fmt.Errorf("unsupported action %s", action)
+	}
+	err := t.WithRetry(ctx, do, stepdef.RetryConnectionError)
+	if err == nil {
+		// the retries may have been cut short before the action was tried
+		err = ctx.Err()
+	}
+
+	who := "the kubeconfig user"
+	if t.Identity != nil {
+		who = t.Identity.String()
+	}
+	// authorization happens first, these errors can only be returned once the action was allowed
+	isForbidden := k8sErrors.IsForbidden(err)
+	if !isForbidden && err != nil && !isAuthorized(err) {
+		return fmt.Errorf("could not tell whether %s is forbidden from %s %s: %w", who, action, ref.GetName(), err)
+	}
+	if forbidden && !isForbidden {
+		return fmt.Errorf("expected %s to be forbidden from %s %s but it was allowed", who, action, ref.GetName())
+	}
+	if !forbidden && isForbidden {
+		return fmt.Errorf("expected %s to be allowed %s %s: %w", who, action, ref.GetName(), err)
+	}
+	return nil
+}
+
+// isAuthorized is true if the error is returned by the API server after the request was authorized
+func isAuthorized(err error) bool {
+	return k8sErrors.IsInvalid(err) || k8sErrors.IsNotFound(err) || k8sErrors.IsAlreadyExists(err) || k8sErrors.IsConflict(err)
+}
@@ -0,0 +1,80 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Being forbidden", func() {
+	var (
+		ctx     context.Context
+		deletes int
+	)
+
+	// deleting runs the step against a client whose deletes return the errors in turn
+	deleting := func(forbidden bool, errs ...error) error {
+		deletes = 0
+		c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(stepdef.Scheme).Build(), interceptor.Funcs{
+			Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				deletes++
+				if deletes <= len(errs) {
+					return errs[deletes-1]
+				}
+				return nil
+			},
+		})
+
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetNamespace("default")
+		cm.SetName("example")
+		return IShouldBeForbiddenFunc(ctx, &stepdef.T{Client: c}, forbidden, "deleting", cm)
+	}
+
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(store.NewStoreFor(context.Background()), 5*time.Second)
+		DeferCleanup(cancel)
+	})
+
+	It("should be forbidden when the API server forbids it", func() {
+		Expect(deleting(true, k8sErrors.NewForbidden(configMaps, "example", errors.New("denied")))).Should(Succeed())
+		Expect(deleting(false, k8sErrors.NewForbidden(configMaps, "example", errors.New("denied")))).Should(MatchError(ContainSubstring("expected the kubeconfig user to be allowed")))
+	})
+
+	It("should be allowed when the request got past authorization", func() {
+		Expect(deleting(false)).Should(Succeed())
+		Expect(deleting(false, k8sErrors.NewNotFound(configMaps, "example"))).Should(Succeed())
+		Expect(deleting(true, k8sErrors.NewNotFound(configMaps, "example"))).Should(MatchError(ContainSubstring("but it was allowed")))
+	})
+
+	It("should not tell when the API server fails", func() {
+		err := deleting(false, k8sErrors.NewInternalError(errors.New(`failed calling webhook "validate.example.com"`)))
+		Expect(err).Should(MatchError(ContainSubstring("could not tell whether the kubeconfig user is forbidden")))
+		Expect(deletes).Should(Equal(1))
+	})
+
+	It("should retry when the API server cannot be reached", func() {
+		refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+		Expect(deleting(false, refused)).Should(Succeed())
+		Expect(deletes).Should(Equal(2))
+	})
+})
@@ -2,6 +2,7 @@ package steps
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/onsi/gomega/gbytes"
@@ -13,7 +14,10 @@ import (
 
 var AsyncAssertExecFunc = func(ctx context.Context, assert stepdef.Assert, timeout time.Duration, ref *unstructured.Unstructured, desiredMatch bool, text string) (err error) {
 
-	session := store.Load[PodSession](ctx, client.ObjectKeyFromObject(ref).String())
+	session, err := store.Load[*PodSession](ctx, client.ObjectKeyFromObject(ref).String())
+	if err != nil {
+		return fmt.Errorf("%s has not been exec'd: %w", ref.GetName(), err)
+	}
 	matcher := gbytes.Say(text)
 
 	_, err = assert(desiredMatch, matcher, session.Out)
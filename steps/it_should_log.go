@@ -30,6 +30,9 @@ var AsyncAssertLogFunc = func(ctx context.Context, t *stepdef.T, assert stepdef.
 	}()
 
 	defer s.Out.CancelDetects()
+	defer func() {
+		t.Attach("logs", "text/plain", s.Out.Contents())
+	}()
 
 	retry := true
 	for retry {
@@ -6,17 +6,24 @@ import (
 
 	"github.com/onsi/gomega/types"
 	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 var AsyncAssertFunc = func(ctx context.Context, t *stepdef.T, assert stepdef.Assert, timeout time.Duration, ref *unstructured.Unstructured, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) (err error) {
+	return AsyncAssertObjectFunc(ctx, t, assert, timeout, ref, desiredMatch, stepdef.NewHaveJSONPathMatcher(jsonpath, matcher))
+}
 
-	matcher = stepdef.NewHaveJSONPathMatcher(jsonpath, matcher)
+// AsyncAssertObjectFunc watches the referenced resource until the matcher, given the whole
+// object, satisfies the assertion or the timeout expires
+var AsyncAssertObjectFunc = func(ctx context.Context, t *stepdef.T, assert stepdef.Assert, timeout time.Duration, ref *unstructured.Unstructured, desiredMatch bool, matcher types.GomegaMatcher) (err error) {
 	deadline := time.After(timeout)
 
 	i, err := t.Client.Watch(ctx, ref, client.InNamespace(ref.GetNamespace()))
@@ -25,6 +32,16 @@ var AsyncAssertFunc = func(ctx context.Context, t *stepdef.T, assert stepdef.Ass
 	}
 	defer i.Stop()
 
+	var last runtime.Object
+	defer func() {
+		if err == nil || last == nil {
+			return
+		}
+		if b, yamlErr := yaml.Marshal(last); yamlErr == nil {
+			t.Attach(ref.GetName()+".yaml", "application/yaml", b)
+		}
+	}()
+
 	err = k8sErrors.NewNotFound(schema.GroupResource{Group: ref.GetObjectKind().GroupVersionKind().Group, Resource: ref.GetKind()}, ref.GetName())
 	for {
 		select {
@@ -47,6 +64,7 @@ var AsyncAssertFunc = func(ctx context.Context, t *stepdef.T, assert stepdef.Ass
 			if event.Object.(client.Object).GetName() != ref.GetName() {
 				continue
 			}
+			last = event.Object
 			t.Log.Info("event triggered assertion", "event", event)
 			var retry bool
 			retry, err = assert(desiredMatch, matcher, event.Object)
@@ -76,8 +94,17 @@ var AsyncAssertWithTimeout = stepdef.StepDefinition{
 		  """
 		And I create cm
 		Then within 1s cm jsonpath '{.metadata.uid}' should not be empty`,
-	StepArg:  stepdef.NoStepArg,
-	Function: AsyncAssertFunc,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, assert stepdef.Assert, timeout time.Duration, ref string, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) error {
+		if obj, ok := dryRunResult(ctx, ref); ok {
+			return assertJSONPath(obj, jsonpath, desiredMatch, matcher)
+		}
+		obj, err := store.Load[*unstructured.Unstructured](ctx, ref)
+		if err != nil {
+			return err
+		}
+		return AsyncAssertFunc(ctx, t, assert, timeout, obj, jsonpath, desiredMatch, matcher)
+	},
 }
 
 var AsyncAssert = stepdef.StepDefinition{
@@ -96,7 +123,14 @@ var AsyncAssert = stepdef.StepDefinition{
 		And I create cm
 		Then cm jsonpath '{.metadata.uid}' should not be empty`,
 	StepArg: stepdef.NoStepArg,
-	Function: func(ctx context.Context, t *stepdef.T, ref *unstructured.Unstructured, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) (err error) {
-		return AsyncAssertFunc(ctx, t, stepdef.Eventually, time.Second, ref, jsonpath, desiredMatch, matcher)
+	Function: func(ctx context.Context, t *stepdef.T, ref string, jsonpath string, desiredMatch bool, matcher types.GomegaMatcher) (err error) {
+		if obj, ok := dryRunResult(ctx, ref); ok {
+			return assertJSONPath(obj, jsonpath, desiredMatch, matcher)
+		}
+		obj, err := store.Load[*unstructured.Unstructured](ctx, ref)
+		if err != nil {
+			return err
+		}
+		return AsyncAssertFunc(ctx, t, stepdef.Eventually, time.Second, obj, jsonpath, desiredMatch, matcher)
 	},
 }
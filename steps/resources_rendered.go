@@ -0,0 +1,72 @@
+package steps
+
+import (
+	"context"
+
+	messages "github.com/cucumber/messages/go/v21"
+	"github.com/testernetes/bdk/stepdef"
+)
+
+var ResourcesFromKustomization = stepdef.StepDefinition{
+	Name: "resources-from-kustomization",
+	Text: "^{reference} from kustomization {filename}$",
+	Help: `Renders the kustomization in the directory with kustomize build, or kubectl kustomize if
+kustomize is not installed, and assigns the rendered resources to a resource set. Steps
+referring to the set act on each of its members, e.g. I create app creates every resource and
+deletes them once the scenario has finished. A member can be referred to as set/kind/name.`,
+	Examples: `
+	Given resources from kustomization ./overlays/test
+	When I create resources
+	Then resources/deployment/example jsonpath '{.spec.replicas}' should be == 3`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, ref string, dir string) error {
+		set, err := stepdef.RenderKustomization(ctx, dir)
+		if err != nil {
+			return err
+		}
+		return stepdef.SaveResourceSet(ctx, ref, set)
+	},
+}
+
+var ResourcesFromHelmChart = stepdef.StepDefinition{
+	Name: "resources-from-helm-chart",
+	Text: "^{reference} from helm chart {filename}$",
+	Help: `Renders a local helm chart, a directory or a packaged chart, with helm template and the
+default values, and assigns the rendered resources to a resource set. The release is named
+after the reference. No chart repository is needed.`,
+	Examples: `
+	Given resources from helm chart ./charts/app
+	When I apply resources`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, ref string, chart string) error {
+		return helmChartFunc(ctx, ref, chart, nil)
+	},
+}
+
+var ResourcesFromHelmChartWithValues = stepdef.StepDefinition{
+	Name: "resources-from-helm-chart-with-values",
+	Text: "^{reference} from helm chart {filename} with values$",
+	Help: `Renders a local helm chart with helm template and the values given in the DocString, and
+assigns the rendered resources to a resource set. The release is named after the reference.`,
+	Examples: `
+	Given resources from helm chart ./charts/app with values
+	  """yaml
+	  replicaCount: 3
+	  image:
+	    tag: ${TAG}
+	  """
+	When I create resources
+	Then resources/deployment/resources-app jsonpath '{.spec.replicas}' should be == 3`,
+	StepArg: stepdef.HelmValues,
+	Function: func(ctx context.Context, ref string, chart string, values *messages.DocString) error {
+		return helmChartFunc(ctx, ref, chart, []byte(values.Content))
+	},
+}
+
+func helmChartFunc(ctx context.Context, ref, chart string, values []byte) error {
+	set, err := stepdef.RenderHelmChart(ctx, ref, chart, values)
+	if err != nil {
+		return err
+	}
+	return stepdef.SaveResourceSet(ctx, ref, set)
+}
@@ -0,0 +1,56 @@
+package steps
+
+import (
+	"context"
+	"time"
+
+	"github.com/testernetes/bdk/stepdef"
+	"github.com/testernetes/bdk/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var ShouldHaveConditionWithTimeout = stepdef.StepDefinition{
+	Name: "should-have-condition-duration",
+	Text: "^{assertion} {duration} {reference} {should|should not} have condition {condition}$",
+	Help: `Asserts that the referenced resource will have, or keep having, the condition in its
+status.conditions within the duration. The reason and message are optional, the message matches
+any message containing the text. A condition whose observedGeneration is older than the
+generation of the resource is stale and does not match, so the controller must have observed
+the latest change. The current conditions are printed as a table when the assertion fails.`,
+	Examples: `
+	Given I create db
+	Then within 2m db should have condition Ready=True with reason Provisioned
+	And for 30s db should not have condition Degraded
+	And within 1m db should have condition Ready with message 'all replicas are ready'`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, assert stepdef.Assert, timeout time.Duration, ref string, desiredMatch bool, condition stepdef.Condition) error {
+		return assertCondition(ctx, t, assert, timeout, ref, desiredMatch, condition)
+	},
+}
+
+var ShouldHaveCondition = stepdef.StepDefinition{
+	Name: "should-have-condition",
+	Text: "^{reference} {should|should not} have condition {condition}$",
+	Help: `Asserts that the referenced resource has the condition in its status.conditions. See
+should-have-condition-duration to wait for a controller.`,
+	Examples: `
+	Then db should have condition Ready=True
+	And db should have condition Synced=False with reason ReconcileError`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, ref string, desiredMatch bool, condition stepdef.Condition) error {
+		return assertCondition(ctx, t, stepdef.Eventually, time.Second, ref, desiredMatch, condition)
+	},
+}
+
+func assertCondition(ctx context.Context, t *stepdef.T, assert stepdef.Assert, timeout time.Duration, ref string, desiredMatch bool, condition stepdef.Condition) error {
+	matcher := stepdef.HaveCondition(condition)
+	if obj, ok := dryRunResult(ctx, ref); ok {
+		_, err := stepdef.Eventually(desiredMatch, matcher, obj)
+		return err
+	}
+	obj, err := store.Load[*unstructured.Unstructured](ctx, ref)
+	if err != nil {
+		return err
+	}
+	return AsyncAssertObjectFunc(ctx, t, assert, timeout, obj, desiredMatch, matcher)
+}
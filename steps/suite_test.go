@@ -0,0 +1,13 @@
+package steps
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSteps(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "steps suite")
+}
@@ -2,36 +2,154 @@ package steps
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/testernetes/bdk/stepdef"
 	authzv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var GroupHavePermission = stepdef.StepDefinition{
-	Name: "group-has-permission",
-	Text: "^group {text} should be able to {verb} {resource} in {namespace}$",
-	Help: `Checks that a group has permissions`,
+var SubjectHasPermission = stepdef.StepDefinition{
+	Name: "subject-has-permission",
+	Text: "^{subject} {should|should not} be able to {verb} {resource} in {namespace}$",
+	Help: `Checks whether a user, group or service account is allowed to perform an action in a
+namespace with a LocalSubjectAccessReview. Nothing is created, only authorization is checked.`,
 	Examples: `
-	Then group atlas-admins should be able to create pods in default`,
-	StepArg:  stepdef.CreateOptions,
-	Function: GroupHavePermissionFunc,
+	Then group atlas-admins should be able to create pods in default
+	And user alice in groups dev should not be able to get pods/log in kube-system
+	And serviceaccount default/builder should be able to update configmaps named example in default`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, subject stepdef.Identity, allowed bool, verb string, resource authzv1.ResourceAttributes, namespace string) error {
+		resource.Verb = verb
+		resource.Namespace = namespace
+		return checkPermission(ctx, t, subject, stepdef.Permission{ResourceAttributes: resource, Allowed: allowed})
+	},
 }
 
-var GroupHavePermissionFunc = func(ctx context.Context, t *stepdef.T, group, namespace string) error {
-	t.Clientset.AuthorizationV1().
-		LocalSubjectAccessReviews(namespace).
+var SubjectHasClusterPermission = stepdef.StepDefinition{
+	Name: "subject-has-cluster-permission",
+	Text: "^{subject} {should|should not} be able to {verb} {resource} cluster-wide$",
+	Help: `Checks whether a user, group or service account is allowed to perform an action on a
+cluster scoped resource, or on a namespaced resource in every namespace, with a SubjectAccessReview.`,
+	Examples: `
+	Then group atlas-admins should be able to list namespaces cluster-wide
+	And serviceaccount default/builder should not be able to delete nodes cluster-wide`,
+	StepArg: stepdef.NoStepArg,
+	Function: func(ctx context.Context, t *stepdef.T, subject stepdef.Identity, allowed bool, verb string, resource authzv1.ResourceAttributes) error {
+		resource.Verb = verb
+		return checkPermission(ctx, t, subject, stepdef.Permission{ResourceAttributes: resource, Allowed: allowed})
+	},
+}
+
+var SubjectHasPermissions = stepdef.StepDefinition{
+	Name: "subject-has-permissions",
+	Text: "^{subject} should have permissions$",
+	Help: `Checks a table of permissions for a user, group or service account. Every permission
+is checked and all of the unexpected ones are reported.`,
+	Examples: `
+	Then user alice in groups dev should have permissions
+	  | verb   | resource         | namespace | allowed |
+	  | get    | pods             | default   | yes     |
+	  | get    | pods/log         | default   | yes     |
+	  | delete | pods             | default   | no      |
+	  | list   | deployments.apps |           | no      |`,
+	StepArg: stepdef.Permissions,
+	Function: func(ctx context.Context, t *stepdef.T, subject stepdef.Identity, permissions []stepdef.Permission) error {
+		return checkPermissions(ctx, t, &subject, permissions)
+	},
+}
+
+var PermissionsShouldBe = stepdef.StepDefinition{
+	Name: "permissions-should-be",
+	Text: "^the permissions should be$",
+	Help: `Checks a permission matrix, a table of permissions with a subject column. Every
+permission is checked and all of the unexpected ones are reported.`,
+	Examples: `
+	Then the permissions should be
+	  | subject                        | verb   | resource                 | namespace | allowed |
+	  | group dev                      | create | deployments.apps         | dev       | yes     |
+	  | group qa                       | create | deployments.apps         | dev       | no      |
+	  | serviceaccount dev/builder     | update | configmaps named release | dev       | yes     |
+	  | user alice                     | delete | namespaces               |           | no      |`,
+	StepArg: stepdef.Permissions,
+	Function: func(ctx context.Context, t *stepdef.T, permissions []stepdef.Permission) error {
+		return checkPermissions(ctx, t, nil, permissions)
+	},
+}
+
+func checkPermissions(ctx context.Context, t *stepdef.T, subject *stepdef.Identity, permissions []stepdef.Permission) error {
+	var errs []error
+	for _, p := range permissions {
+		s := subject
+		if p.Subject != nil {
+			s = p.Subject
+		}
+		if s == nil {
+			errs = append(errs, fmt.Errorf("no subject to check %s for", p))
+			continue
+		}
+		errs = append(errs, checkPermission(ctx, t, *s, p))
+	}
+	return errors.Join(errs...)
+}
+
+func checkPermission(ctx context.Context, t *stepdef.T, subject stepdef.Identity, p stepdef.Permission) error {
+	var status authzv1.SubjectAccessReviewStatus
+	err := t.WithRetry(ctx, func() (err error) {
+		status, err = accessReview(ctx, t, subject, p.ResourceAttributes)
+		return err
+	}, stepdef.RetryK8sError)
+	if err != nil {
+		return err
+	}
+	if status.Allowed == p.Allowed {
+		return nil
+	}
+
+	expected, actual := "allowed", "denied"
+	if !p.Allowed {
+		expected, actual = actual, expected
+	}
+	err = fmt.Errorf("expected %s to be %s to %s but it is %s", subject, expected, p, actual)
+	if status.Reason != "" {
+		err = fmt.Errorf("%w: %s", err, status.Reason)
+	}
+	if status.EvaluationError != "" {
+		err = fmt.Errorf("%w (evaluation error: %s)", err, status.EvaluationError)
+	}
+	return err
+}
+
+// accessReview asks the API server whether the subject may perform the action. Users
+// are reviewed with the system:authenticated group like requests they would make.
+func accessReview(ctx context.Context, t *stepdef.T, subject stepdef.Identity, attrs authzv1.ResourceAttributes) (authzv1.SubjectAccessReviewStatus, error) {
+	spec := authzv1.SubjectAccessReviewSpec{
+		User:               subject.User,
+		Groups:             slices.Clone(subject.Groups),
+		ResourceAttributes: &attrs,
+	}
+	if spec.User != "" && !slices.Contains(spec.Groups, "system:authenticated") {
+		spec.Groups = append(spec.Groups, "system:authenticated")
+	}
+
+	if attrs.Namespace == "" {
+		review, err := t.Clientset.AuthorizationV1().SubjectAccessReviews().
+			Create(ctx, &authzv1.SubjectAccessReview{Spec: spec}, metav1.CreateOptions{})
+		if err != nil {
+			return authzv1.SubjectAccessReviewStatus{}, err
+		}
+		return review.Status, nil
+	}
+
+	review, err := t.Clientset.AuthorizationV1().LocalSubjectAccessReviews(attrs.Namespace).
 		Create(ctx, &authzv1.LocalSubjectAccessReview{
-			Spec: authzv1.SubjectAccessReviewSpec{
-				Groups: []string{group},
-				ResourceAttributes: &authzv1.ResourceAttributes{
-					Verb:     "get",
-					Group:    "",
-					Version:  "*",
-					Resource: "pods",
-				},
-			},
+			ObjectMeta: metav1.ObjectMeta{Namespace: attrs.Namespace},
+			Spec:       spec,
 		}, metav1.CreateOptions{})
-
-	return nil
+	if err != nil {
+		return authzv1.SubjectAccessReviewStatus{}, err
+	}
+	return review.Status, nil
 }
@@ -2,34 +2,224 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-type store struct{}
+// Scope is the lifetime of values in a store. Scopes are layered so that values
+// saved in a suite scope can be loaded by every feature, rule and scenario of the suite.
+type Scope string
 
-func Save[T any](ctx context.Context, key string, value T) {
-	storage := ctx.Value(&store{}).(map[string]any)
+const (
+	SuiteScope    Scope = "suite"
+	FeatureScope  Scope = "feature"
+	RuleScope     Scope = "rule"
+	ScenarioScope Scope = "scenario"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrWrongType = errors.New("wrong type")
+)
+
+// NotFoundError is returned when no scope has a key
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("reference '%s' is not defined in this scenario", e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// WrongTypeError is returned when the value of a key is not of the type requested
+type WrongTypeError struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *WrongTypeError) Error() string {
+	return fmt.Sprintf("reference '%s' is a %s, expected a %s", e.Key, e.Actual, e.Expected)
+}
+
+func (e *WrongTypeError) Is(target error) bool {
+	return target == ErrWrongType
+}
+
+type storeKey struct{}
+
+type store struct {
+	lock     sync.RWMutex
+	scope    Scope
+	parent   *store
+	values   map[string]any
+	cleanups []func() error
+	keyLocks map[string]*sync.Mutex
+}
+
+func from(ctx context.Context) *store {
+	s, ok := ctx.Value(storeKey{}).(*store)
+	if !ok {
+		panic("context does not have a store, use store.NewScope")
+	}
+	return s
+}
+
+// find returns the nearest store of the scope
+func (s *store) find(scope Scope) (*store, error) {
+	for ; s != nil; s = s.parent {
+		if s.scope == scope {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("there is no %s scope", scope)
+}
+
+func (s *store) load(key string) (any, bool) {
+	for ; s != nil; s = s.parent {
+		s.lock.RLock()
+		value, exists := s.values[key]
+		s.lock.RUnlock()
+		if exists {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (s *store) save(key string, value any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = value
+}
 
-	storage[key] = value
+// Save into the innermost scope
+func Save[T any](ctx context.Context, key string, value T) {
+	from(ctx).save(key, value)
 	log.FromContext(ctx).V(1).Info("Store Saved", "Key", key, "Value", value)
 }
 
-// Load or create new value
-func Load[T any](ctx context.Context, key string) T {
-	storage := ctx.Value(&store{}).(map[string]any)
+// SaveTo saves into the nearest scope of the given type so that the value outlives
+// the current scope
+func SaveTo[T any](ctx context.Context, scope Scope, key string, value T) error {
+	s, err := from(ctx).find(scope)
+	if err != nil {
+		return err
+	}
+	s.save(key, value)
+	log.FromContext(ctx).V(1).Info("Store Saved", "Scope", scope, "Key", key, "Value", value)
+	return nil
+}
 
+// Load from the innermost scope which has the key, falling through to outer scopes.
+// A NotFoundError is returned if no scope has the key and a WrongTypeError if the
+// value is not a T.
+func Load[T any](ctx context.Context, key string) (T, error) {
 	var t T
-	if value, exists := storage[key]; exists {
-		log.FromContext(ctx).V(1).Info("Store Loaded", "Key", key, "Value", value)
-		return value.(T)
+	value, exists := from(ctx).load(key)
+	if !exists {
+		log.FromContext(ctx).V(1).Info("Store Loaded", "Key", key, "Value", "NotFound")
+		return t, &NotFoundError{Key: key}
+	}
+	log.FromContext(ctx).V(1).Info("Store Loaded", "Key", key, "Value", value)
+
+	t, ok := value.(T)
+	if !ok {
+		return t, &WrongTypeError{
+			Key:      key,
+			Expected: reflect.TypeOf((*T)(nil)).Elem().String(),
+			Actual:   fmt.Sprintf("%T", value),
+		}
 	}
-	log.FromContext(ctx).V(1).Info("Store Loaded", "Key", key, "Value", "NotFound")
+	return t, nil
+}
 
-	storage[key] = t
-	return t
+// All returns a copy of everything which can be loaded, values in inner scopes
+// take precedence over outer scopes
+func All(ctx context.Context) map[string]any {
+	all := map[string]any{}
+	for s := from(ctx); s != nil; s = s.parent {
+		s.lock.RLock()
+		for key, value := range s.values {
+			if _, exists := all[key]; !exists {
+				all[key] = value
+			}
+		}
+		s.lock.RUnlock()
+	}
+	return all
+}
+
+// Cleanup registers a function to be run when the nearest scope of the given type is closed
+func Cleanup(ctx context.Context, scope Scope, f func() error) error {
+	s, err := from(ctx).find(scope)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cleanups = append(s.cleanups, f)
+	return nil
+}
+
+// Lock locks the key in the nearest scope of the given type until unlock is called. Features
+// run in parallel share the suite scope, steps which create something once per scope hold
+// the lock while they check whether it exists and create it.
+func Lock(ctx context.Context, scope Scope, key string) (unlock func(), err error) {
+	s, err := from(ctx).find(scope)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	if s.keyLocks == nil {
+		s.keyLocks = map[string]*sync.Mutex{}
+	}
+	l, ok := s.keyLocks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		s.keyLocks[key] = l
+	}
+	s.lock.Unlock()
+
+	l.Lock()
+	return l.Unlock, nil
+}
+
+// Close runs the cleanups of the innermost scope in the reverse order they were registered
+func Close(ctx context.Context) (errs error) {
+	s := from(ctx)
+	s.lock.Lock()
+	cleanups := s.cleanups
+	s.cleanups = nil
+	s.lock.Unlock()
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		errs = errors.Join(errs, cleanups[i]())
+	}
+	return errs
+}
+
+// NewScope returns a context with a new store of the given scope nested in the
+// context's current store, if it has one
+func NewScope(ctx context.Context, scope Scope) context.Context {
+	s := &store{
+		scope:  scope,
+		values: make(map[string]any),
+	}
+	if parent, ok := ctx.Value(storeKey{}).(*store); ok {
+		s.parent = parent
+	}
+	return context.WithValue(ctx, storeKey{}, s)
 }
 
 func NewStoreFor(ctx context.Context) context.Context {
-	return context.WithValue(ctx, &store{}, make(map[string]any))
+	return NewScope(ctx, ScenarioScope)
 }
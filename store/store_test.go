@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -22,26 +24,146 @@ var _ = Describe("Store", func() {
 
 		It("should initialize a store into a ctx", func() {
 			ctx = NewStoreFor(ctx)
-			Expect(ctx.Value(&store{})).Should(Equal(map[string]any{}))
+			Expect(All(ctx)).Should(Equal(map[string]any{}))
 		})
 
 		It("should save into a ctx", func() {
 			Save(ctx, "obj", u)
-			Expect(ctx.Value(&store{})).Should(Equal(map[string]any{
+			Expect(All(ctx)).Should(Equal(map[string]any{
 				"obj": u,
 			}))
 		})
 
 		It("should load from a ctx", func() {
-			u := Load[*unstructured.Unstructured](ctx, "obj")
+			u, err := Load[*unstructured.Unstructured](ctx, "obj")
+			Expect(err).ShouldNot(HaveOccurred())
 			Expect(u.GetName()).Should(Equal("me"))
 			u.SetName("bar")
 		})
 
 		It("should load from a ctx", func() {
-			u := Load[*unstructured.Unstructured](ctx, "obj")
+			u, err := Load[*unstructured.Unstructured](ctx, "obj")
+			Expect(err).ShouldNot(HaveOccurred())
 			Expect(u.GetName()).Should(Equal("bar"))
 		})
+
+		It("should return a not found error for a missing key", func() {
+			_, err := Load[*unstructured.Unstructured](ctx, "cm")
+			Expect(err).Should(MatchError(ErrNotFound))
+			Expect(err).Should(MatchError("reference 'cm' is not defined in this scenario"))
+		})
+
+		It("should return a wrong type error for a key of another type", func() {
+			_, err := Load[string](ctx, "obj")
+			Expect(err).Should(MatchError(ErrWrongType))
+			Expect(err).Should(MatchError("reference 'obj' is a *unstructured.Unstructured, expected a string"))
+		})
+
+		It("should return a copy of everything in the store", func() {
+			all := All(ctx)
+			Expect(all).Should(HaveKeyWithValue("obj", u))
+			delete(all, "obj")
+			Expect(All(ctx)).Should(HaveKey("obj"))
+		})
 	})
 
+	Context("Layered scopes", func() {
+		var suite, feature, scenario context.Context
+
+		BeforeEach(func() {
+			suite = NewScope(context.Background(), SuiteScope)
+			feature = NewScope(suite, FeatureScope)
+			scenario = NewStoreFor(feature)
+		})
+
+		It("should fall through to outer scopes", func() {
+			Save(suite, "global", "suite")
+			Save(feature, "fixture", "feature")
+			Expect(Load[string](scenario, "global")).Should(Equal("suite"))
+			Expect(Load[string](scenario, "fixture")).Should(Equal("feature"))
+		})
+
+		It("should prefer inner scopes", func() {
+			Save(feature, "var", "feature")
+			Save(scenario, "var", "scenario")
+			Expect(Load[string](scenario, "var")).Should(Equal("scenario"))
+			Expect(Load[string](feature, "var")).Should(Equal("feature"))
+			Expect(All(scenario)).Should(HaveKeyWithValue("var", "scenario"))
+		})
+
+		It("should not leak values between sibling scopes", func() {
+			Save(scenario, "var", "first")
+			_, err := Load[string](NewStoreFor(feature), "var")
+			Expect(err).Should(MatchError(ErrNotFound))
+		})
+
+		It("should save to an outer scope", func() {
+			Expect(SaveTo(scenario, SuiteScope, "global", "value")).Should(Succeed())
+			Expect(Load[string](NewStoreFor(NewScope(suite, FeatureScope)), "global")).Should(Equal("value"))
+		})
+
+		It("should fail to save to a scope which does not exist", func() {
+			Expect(SaveTo(scenario, RuleScope, "var", "value")).ShouldNot(Succeed())
+		})
+
+		It("should be safe to use from multiple goroutines", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					scenario := NewStoreFor(feature)
+					key := fmt.Sprintf("var-%d", i)
+					Save(scenario, key, i)
+					Expect(SaveTo(scenario, SuiteScope, key, i)).Should(Succeed())
+					Expect(Load[int](scenario, key)).Should(Equal(i))
+					All(scenario)
+				}(i)
+			}
+			wg.Wait()
+			Expect(All(suite)).Should(HaveLen(10))
+		})
+
+		It("should let one feature at a time check and save a key of a shared scope", func() {
+			var wg sync.WaitGroup
+			var created int
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					scenario := NewStoreFor(NewScope(suite, FeatureScope))
+					unlock, err := Lock(scenario, SuiteScope, "operator")
+					Expect(err).ShouldNot(HaveOccurred())
+					defer unlock()
+					if _, err := Load[string](scenario, "operator"); err == nil {
+						return
+					}
+					created++
+					Expect(SaveTo(scenario, SuiteScope, "operator", "installed")).Should(Succeed())
+				}()
+			}
+			wg.Wait()
+			Expect(created).Should(Equal(1))
+		})
+
+		It("should run cleanups in reverse order when the scope is closed", func() {
+			var order []string
+			Expect(Cleanup(scenario, FeatureScope, func() error {
+				order = append(order, "first")
+				return nil
+			})).Should(Succeed())
+			Expect(Cleanup(scenario, FeatureScope, func() error {
+				order = append(order, "second")
+				return nil
+			})).Should(Succeed())
+
+			Expect(Close(scenario)).Should(Succeed())
+			Expect(order).Should(BeEmpty())
+
+			Expect(Close(feature)).Should(Succeed())
+			Expect(order).Should(Equal([]string{"second", "first"}))
+		})
+	})
 })